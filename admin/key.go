@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"net/http"
+)
+
+// RotateKey loads the AFC key from its key source again, eg(after the aws secret is rotated), and returns the new
+// validator address
+func (admin *Admin) RotateKey(w http.ResponseWriter, r *http.Request) {
+	address, err := admin.AFCExecutor.RotateKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := struct {
+		Address string `json:"address"`
+	}{
+		Address: address.String(),
+	}
+	writeJSON(w, http.StatusOK, result)
+}
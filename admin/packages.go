@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+)
+
+const (
+	DefaultPackagePageLimit = 50
+	MaxPackagePageLimit     = 500
+)
+
+type PackageList struct {
+	Total    int64                         `json:"total"`
+	Page     int64                         `json:"page"`
+	Limit    int64                         `json:"limit"`
+	Packages []*model.CrossChainPackageLog `json:"packages"`
+}
+
+// packageFilters lists the query parameters which are matched against the column of the same name
+var packageFilters = []string{
+	"chain_id",
+	"oracle_sequence",
+	"package_sequence",
+	"channel_id",
+	"height",
+	"tx_hash",
+	"claim_tx_hash",
+}
+
+// numericPackageFilters lists the filters which should be numbers
+var numericPackageFilters = map[string]bool{
+	"chain_id":         true,
+	"oracle_sequence":  true,
+	"package_sequence": true,
+	"channel_id":       true,
+	"height":           true,
+}
+
+// filterPackages applies the filters in the query parameters to the package query
+func filterPackages(query *gorm.DB, r *http.Request) (*gorm.DB, error) {
+	params := r.URL.Query()
+
+	for _, filter := range packageFilters {
+		value := params.Get(filter)
+		if value == "" {
+			continue
+		}
+		if numericPackageFilters[filter] {
+			num, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s should be a non-negative number", filter)
+			}
+			query = query.Where(fmt.Sprintf("%s = ?", filter), num)
+		} else {
+			query = query.Where(fmt.Sprintf("%s = ?", filter), value)
+		}
+	}
+
+	if value := params.Get("status"); value != "" {
+		status, err := model.ParsePackageStatus(value)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("status = ?", status)
+	}
+	return query, nil
+}
+
+// parsePage returns the page and limit in the query parameters
+func parsePage(r *http.Request) (int64, int64, error) {
+	params := r.URL.Query()
+
+	page := int64(1)
+	if value := params.Get("page"); value != "" {
+		num, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || num <= 0 {
+			return 0, 0, fmt.Errorf("page should be larger than 0")
+		}
+		page = num
+	}
+
+	limit := int64(DefaultPackagePageLimit)
+	if value := params.Get("limit"); value != "" {
+		num, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || num <= 0 || num > MaxPackagePageLimit {
+			return 0, 0, fmt.Errorf("limit should be between 1 and %d", MaxPackagePageLimit)
+		}
+		limit = num
+	}
+	return page, limit, nil
+}
+
+// Packages returns the cross-chain packages matching the filters in the query parameters
+func (admin *Admin) Packages(w http.ResponseWriter, r *http.Request) {
+	page, limit, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, err := filterPackages(admin.DB.Model(model.CrossChainPackageLog{}), r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list := PackageList{
+		Page:     page,
+		Limit:    limit,
+		Packages: make([]*model.CrossChainPackageLog, 0),
+	}
+	if err := query.Count(&list.Total).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = query.Order("oracle_sequence desc, id desc").Offset((page - 1) * limit).Limit(limit).Find(&list.Packages).Error
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// Package returns the cross-chain package of the given id
+func (admin *Admin) Package(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "id should be a number", http.StatusBadRequest)
+		return
+	}
+
+	packageLog := &model.CrossChainPackageLog{}
+	err = admin.DB.Where("id = ?", id).First(packageLog).Error
+	if err == gorm.ErrRecordNotFound {
+		http.Error(w, "package not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, packageLog)
+}
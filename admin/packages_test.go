@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestAdmin_Packages(t *testing.T) {
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  seq,
+			PackageSequence: seq,
+			ChannelId:       2,
+			Height:          int64(seq),
+			Status:          model.PackageStatusConfirmed,
+			TxHash:          "tx_hash",
+		})
+	}
+	db.Create(&model.CrossChainPackageLog{
+		ChainId:         96,
+		OracleSequence:  4,
+		PackageSequence: 1,
+		ChannelId:       3,
+		Height:          4,
+		Status:          model.PackageStatusInit,
+		TxHash:          "tx_hash_2",
+	})
+
+	admin := NewAdmin(config, db, nil, nil, nil, nil, nil, nil)
+
+	cases := []struct {
+		query     string
+		code      int
+		total     int64
+		sequences []uint64
+	}{
+		{"", http.StatusOK, 4, []uint64{4, 3, 2, 1}},
+		{"?channel_id=2&limit=2", http.StatusOK, 3, []uint64{3, 2}},
+		{"?channel_id=2&limit=2&page=2", http.StatusOK, 3, []uint64{1}},
+		{"?status=init", http.StatusOK, 1, []uint64{4}},
+		{"?status=1&height=2", http.StatusOK, 1, []uint64{2}},
+		{"?tx_hash=tx_hash_2", http.StatusOK, 1, []uint64{4}},
+		{"?status=wrong", http.StatusBadRequest, 0, nil},
+		{"?oracle_sequence=-1", http.StatusBadRequest, 0, nil},
+		{"?limit=501", http.StatusBadRequest, 0, nil},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/packages"+c.query, nil)
+		rec := httptest.NewRecorder()
+		admin.Packages(rec, req)
+
+		require.Equal(t, c.code, rec.Code, c.query)
+		if c.code != http.StatusOK {
+			continue
+		}
+
+		list := PackageList{}
+		err := json.Unmarshal(rec.Body.Bytes(), &list)
+		require.Nil(t, err, "error should be nil")
+		require.Equal(t, c.total, list.Total, c.query)
+
+		sequences := make([]uint64, 0, len(list.Packages))
+		for _, pack := range list.Packages {
+			sequences = append(sequences, pack.OracleSequence)
+		}
+		require.Equal(t, c.sequences, sequences, c.query)
+	}
+}
+
+func TestAdmin_Package(t *testing.T) {
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	packageLog := &model.CrossChainPackageLog{
+		ChainId:         96,
+		OracleSequence:  1,
+		PackageSequence: 1,
+		ChannelId:       2,
+		Height:          1,
+		TxHash:          "tx_hash",
+	}
+	db.Create(packageLog)
+
+	admin := NewAdmin(config, db, nil, nil, nil, nil, nil, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/packages/{id}", admin.Package)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/packages/1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	result := &model.CrossChainPackageLog{}
+	err = json.Unmarshal(rec.Body.Bytes(), result)
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, packageLog.TxHash, result.TxHash)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/packages/2", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/pool"
+)
+
+type PoolStatus struct {
+	Name      string                `json:"name"`
+	Providers []*pool.ProviderState `json:"providers"`
+}
+
+// Providers returns the health state of the providers of Axim Chain and every source chain, it is authenticated since
+// the provider urls and errors may contain api keys
+func (admin *Admin) Providers(w http.ResponseWriter, r *http.Request) {
+	pools := make([]*PoolStatus, 0, len(admin.Pools))
+	for _, p := range admin.Pools {
+		pools = append(pools, &PoolStatus{
+			Name:      p.Name,
+			Providers: p.States(),
+		})
+	}
+	writeJSON(w, http.StatusOK, pools)
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/recovery"
+)
+
+// authenticated wraps the handler with bearer token authentication, the handler is disabled if no auth token
+// is configured
+func (admin *Admin) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authToken := admin.Config.AdminAuthToken()
+		if authToken == "" {
+			http.Error(w, "endpoint is disabled, auth_token of admin_config is not set", http.StatusForbidden)
+			return
+		}
+
+		expected := []byte("Bearer " + authToken)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// Recover re-fetches the packages of an ASC tx or block range and attaches the missing ones to the next
+// pending oracle sequence
+func (admin *Admin) Recover(w http.ResponseWriter, r *http.Request) {
+	req := &recovery.Request{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rec := recovery.FindRecovery(admin.Recoveries, req.ChainId)
+	if rec == nil {
+		http.Error(w, "chain_id should be one of the source chains", http.StatusBadRequest)
+		return
+	}
+
+	result, err := rec.Recover(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"net/http"
+)
+
+// Reload reloads the config fields which can be changed without restart, same as sending SIGHUP
+func (admin *Admin) Reload(w http.ResponseWriter, r *http.Request) {
+	changed, err := admin.Reloader.Reload()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := struct {
+		Changed []string `json:"changed"`
+	}{
+		Changed: changed,
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -7,8 +7,15 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/Sotatek-huytran2/oracle-relayer/executor/afc"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/pool"
+	"github.com/Sotatek-huytran2/oracle-relayer/observer"
+	"github.com/Sotatek-huytran2/oracle-relayer/recovery"
+	"github.com/Sotatek-huytran2/oracle-relayer/relayer"
+	"github.com/Sotatek-huytran2/oracle-relayer/reload"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
 
@@ -18,13 +25,28 @@ const (
 
 type Admin struct {
 	Config      *util.Config
+	DB          *gorm.DB
+	Observers   []*observer.Observer
+	Relayer     *relayer.Relayer
 	AFCExecutor *afc.Executor
+	Recoveries  []*recovery.Recovery
+	Pools       []*pool.Pool
+	Reloader    *reload.Reloader
 }
 
-func NewAdmin(config *util.Config, executor *afc.Executor) *Admin {
+// NewAdmin returns the admin server instance, observers and recoveries are those of every source chain, pools
+// are the provider pools of all the executors and the reloader reloads the config
+func NewAdmin(config *util.Config, db *gorm.DB, observers []*observer.Observer, oracleRelayer *relayer.Relayer,
+	executor *afc.Executor, recoveries []*recovery.Recovery, pools []*pool.Pool, reloader *reload.Reloader) *Admin {
 	return &Admin{
 		Config:      config,
+		DB:          db,
+		Observers:   observers,
+		Relayer:     oracleRelayer,
 		AFCExecutor: executor,
+		Recoveries:  recoveries,
+		Pools:       pools,
+		Reloader:    reloader,
 	}
 }
 
@@ -32,27 +54,27 @@ func (admin *Admin) Endpoints(w http.ResponseWriter, r *http.Request) {
 	endpoints := struct {
 		Endpoints []string `json:"endpoints"`
 	}{
-		Endpoints: []string{},
+		Endpoints: []string{"/metrics", "/status", "/healthz", "/readyz", "/packages", "/packages/{id}", "/recover",
+			"/providers", "/reload", "/key/rotate"},
 	}
 
-	jsonBytes, err := json.MarshalIndent(endpoints, "", "    ")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
-	if err != nil {
-		util.Logger.Errorf("write response error, err=%s", err.Error())
-	}
+	writeJSON(w, http.StatusOK, endpoints)
 }
 
 func (admin *Admin) Serve() {
 	router := mux.NewRouter()
 
 	router.HandleFunc("/", admin.Endpoints)
+	router.Handle("/metrics", promhttp.Handler())
+	router.HandleFunc("/status", admin.Status)
+	router.HandleFunc("/healthz", admin.Healthz)
+	router.HandleFunc("/readyz", admin.Readyz)
+	router.HandleFunc("/packages", admin.Packages).Methods(http.MethodGet)
+	router.HandleFunc("/packages/{id}", admin.Package).Methods(http.MethodGet)
+	router.HandleFunc("/recover", admin.authenticated(admin.Recover)).Methods(http.MethodPost)
+	router.HandleFunc("/providers", admin.authenticated(admin.Providers)).Methods(http.MethodGet)
+	router.HandleFunc("/reload", admin.authenticated(admin.Reload)).Methods(http.MethodPost)
+	router.HandleFunc("/key/rotate", admin.authenticated(admin.RotateKey)).Methods(http.MethodPost)
 
 	listenAddr := DefaultListenAddr
 	if admin.Config.AdminConfig != nil && admin.Config.AdminConfig.ListenAddr != "" {
@@ -72,3 +94,19 @@ func (admin *Admin) Serve() {
 		panic(fmt.Sprintf("start admin server error, err=%s", err.Error()))
 	}
 }
+
+// writeJSON writes the indented json of v to the response with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	jsonBytes, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		util.Logger.Errorf("write response error, err=%s", err.Error())
+	}
+}
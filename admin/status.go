@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/observer"
+)
+
+type BlockStatus struct {
+	Height         int64  `json:"height"`
+	BlockHash      string `json:"block_hash"`
+	BlockTime      int64  `json:"block_time"`
+	FetchTime      int64  `json:"fetch_time"`
+	AgeInSeconds   int64  `json:"age_in_seconds"`
+	TimeOutSeconds int64  `json:"time_out_seconds"`
+	Stale          bool   `json:"stale"`
+}
+
+type PackageStatus struct {
+	OracleSequence  uint64 `json:"oracle_sequence"`
+	PackageSequence uint64 `json:"package_sequence"`
+	ChannelId       uint8  `json:"channel_id"`
+	Height          int64  `json:"height"`
+	TxHash          string `json:"tx_hash"`
+	ConfirmTime     int64  `json:"confirm_time"`
+	AgeInSeconds    int64  `json:"age_in_seconds"`
+}
+
+type ChainStatus struct {
+	ChainId                uint16         `json:"chain_id"`
+	ChainName              string         `json:"chain_name"`
+	Block                  *BlockStatus   `json:"block"`
+	OracleSequence         *int64         `json:"oracle_sequence"`
+	OldestUnclaimedPackage *PackageStatus `json:"oldest_unclaimed_package"`
+}
+
+type Status struct {
+	DBReachable bool           `json:"db_reachable"`
+	Chains      []*ChainStatus `json:"chains"`
+	Errors      []string       `json:"errors"`
+}
+
+// healthy returns whether the db is reachable and blocks of every source chain are being fetched
+func (s *Status) healthy() bool {
+	if !s.DBReachable {
+		return false
+	}
+	for _, chain := range s.Chains {
+		if chain.Block == nil || chain.Block.Stale {
+			return false
+		}
+	}
+	return true
+}
+
+// ready returns whether the relayer is able to relay packages of every source chain to Axim Chain
+func (s *Status) ready() bool {
+	if !s.healthy() {
+		return false
+	}
+	for _, chain := range s.Chains {
+		if chain.OracleSequence == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// getStatus collects the status of the observers, the relayer and the database
+func (admin *Admin) getStatus() *Status {
+	status := &Status{
+		Chains: make([]*ChainStatus, 0, len(admin.Observers)),
+		Errors: make([]string, 0),
+	}
+
+	if err := admin.DB.DB().Ping(); err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("ping db error, err=%s", err.Error()))
+		return status
+	}
+	status.DBReachable = true
+
+	for _, ob := range admin.Observers {
+		chainStatus, errs := admin.getChainStatus(ob)
+		status.Chains = append(status.Chains, chainStatus)
+		status.Errors = append(status.Errors, errs...)
+	}
+	return status
+}
+
+// getChainStatus collects the status of the source chain of the given observer
+func (admin *Admin) getChainStatus(ob *observer.Observer) (*ChainStatus, []string) {
+	chainId := ob.ChainConfig.ASCChainId
+	status := &ChainStatus{
+		ChainId:   chainId,
+		ChainName: ob.ChainConfig.ASCChainName,
+	}
+	errs := make([]string, 0)
+	now := time.Now().Unix()
+
+	blockLog, err := ob.GetCurrentBlockLog()
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("get current block log error, chain_id=%d, err=%s", chainId, err.Error()))
+	} else {
+		status.Block = &BlockStatus{
+			Height:         blockLog.Height,
+			BlockHash:      blockLog.BlockHash,
+			BlockTime:      blockLog.BlockTime,
+			FetchTime:      blockLog.CreateTime,
+			TimeOutSeconds: admin.Config.Alert().BlockUpdateTimeOut,
+		}
+		if blockLog.Height > 0 {
+			status.Block.AgeInSeconds = now - blockLog.CreateTime
+			status.Block.Stale = status.Block.AgeInSeconds > status.Block.TimeOutSeconds
+		}
+	}
+
+	sequence, err := admin.AFCExecutor.GetCurrentSequence(chainId)
+	if err != nil {
+		// the oldest unclaimed package can not be told without the current sequence
+		errs = append(errs, fmt.Sprintf("get current sequence error, chain_id=%d, err=%s", chainId, err.Error()))
+		return status, errs
+	}
+	status.OracleSequence = &sequence
+
+	claimLog, err := admin.Relayer.GetOldestConfirmedPackage(chainId, sequence)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("query oldest unclaimed package error, chain_id=%d, err=%s", chainId, err.Error()))
+	} else if claimLog != nil {
+		status.OldestUnclaimedPackage = &PackageStatus{
+			OracleSequence:  claimLog.OracleSequence,
+			PackageSequence: claimLog.PackageSequence,
+			ChannelId:       claimLog.ChannelId,
+			Height:          claimLog.Height,
+			TxHash:          claimLog.TxHash,
+			ConfirmTime:     claimLog.UpdateTime,
+			AgeInSeconds:    now - claimLog.UpdateTime,
+		}
+	}
+
+	return status, errs
+}
+
+// Status returns the status of the observer, the relayer and the database
+func (admin *Admin) Status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, admin.getStatus())
+}
+
+// Healthz is the liveness probe, it fails if the db is unreachable or no block of any chain is fetched in time
+func (admin *Admin) Healthz(w http.ResponseWriter, r *http.Request) {
+	status := admin.getStatus()
+	if !status.healthy() {
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// Readyz is the readiness probe, it also fails if Axim Chain is unreachable
+func (admin *Admin) Readyz(w http.ResponseWriter, r *http.Request) {
+	status := admin.getStatus()
+	if !status.ready() {
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
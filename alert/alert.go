@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+const (
+	IncidentDedupKeyBlockTimeout    = "block_timeout"
+	IncidentDedupKeyRelayError      = "relay_error"
+	IncidentDedupKeyReorg           = "reorg"
+	IncidentDedupKeySequenceGap     = "sequence_gap"
+	IncidentDedupKeyPayloadMismatch = "payload_mismatch"
+)
+
+// ChainDedupKey returns the incident dedup key of the given source chain
+func ChainDedupKey(dedupKey string, chainId uint16) string {
+	return fmt.Sprintf("%s_%d", dedupKey, chainId)
+}
+
+// Alert is the alert of a condition, alerts of the same condition share the same dedup key. Resolved alerts tell
+// that the condition has cleared.
+type Alert struct {
+	DedupKey string
+	Severity string
+	Message  string
+	Resolved bool
+}
+
+// Alerter sends alerts to one destination
+type Alerter interface {
+	Name() string
+	Send(alert *Alert) error
+}
+
+var (
+	mtx      sync.RWMutex
+	alerters []Alerter
+)
+
+// Init registers the alerters configured in the alert config, the alerters registered before are removed. Nothing
+// is changed if any alerter can not be created.
+func Init(cfg *util.AlertConfig) error {
+	newAlerters, err := NewAlerters(cfg)
+	if err != nil {
+		return err
+	}
+
+	mtx.Lock()
+	alerters = newAlerters
+	mtx.Unlock()
+
+	defaultManager.SetRepeatInterval(time.Duration(cfg.RepeatInterval) * time.Second)
+	return nil
+}
+
+// Register registers the alerter, alerts are sent to it as well as the alerters registered before
+func Register(alerter Alerter) {
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	alerters = append(alerters, alerter)
+}
+
+// NewAlerters returns the alerters of the destinations set in the alert config
+func NewAlerters(cfg *util.AlertConfig) ([]Alerter, error) {
+	result := make([]Alerter, 0)
+	if cfg.TelegramBotId != "" && cfg.TelegramChatId != "" {
+		result = append(result, NewTelegramAlerter(cfg.TelegramBotId, cfg.TelegramChatId))
+	}
+	if cfg.PagerDutyAuthToken != "" {
+		pagerDutyAlerter, err := NewPagerDutyAlerter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pagerDutyAlerter)
+	}
+	if cfg.SlackWebhookUrl != "" {
+		result = append(result, NewSlackAlerter(cfg.SlackWebhookUrl))
+	}
+	if cfg.WebhookUrl != "" {
+		result = append(result, NewWebhookAlerter(cfg.Moniker, cfg.WebhookUrl, cfg.WebhookHeaders))
+	}
+	if cfg.SmtpHost != "" {
+		result = append(result, NewSmtpAlerter(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword,
+			cfg.SmtpFrom, cfg.SmtpTo))
+	}
+	return result, nil
+}
+
+// Send sends the alert to every registered alerter at once, it is for one-off events which are never resolved.
+// Alerts of conditions which last for a while should be sent by Fire and Resolve.
+func Send(dedupKey string, severity string, msg string) {
+	send(&Alert{
+		DedupKey: dedupKey,
+		Severity: severity,
+		Message:  msg,
+	})
+}
+
+// Fire sends the alert of a condition which holds, see Manager.Fire
+func Fire(dedupKey string, severity string, msg string) {
+	defaultManager.Fire(dedupKey, severity, msg)
+}
+
+// Resolve sends the recovery message of a condition which has cleared, see Manager.Resolve
+func Resolve(dedupKey string, msg string) {
+	defaultManager.Resolve(dedupKey, msg)
+}
+
+// send sends the alert to every registered alerter, errors of alerters are logged
+func send(alert *Alert) {
+	if alert.Message == "" {
+		return
+	}
+
+	mtx.RLock()
+	registered := alerters
+	mtx.RUnlock()
+
+	dedupKey := alert.DedupKey
+	for _, alerter := range registered {
+		if err := alerter.Send(alert); err != nil {
+			util.Logger.Errorf("send alert error, alerter=%s, dedup_key=%s, err=%s", alerter.Name(), dedupKey, err.Error())
+		}
+	}
+}
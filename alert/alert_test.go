@@ -0,0 +1,184 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+type recordAlerter struct {
+	alerts []*Alert
+	err    error
+}
+
+func (a *recordAlerter) Name() string {
+	return "record"
+}
+
+func (a *recordAlerter) Send(alert *Alert) error {
+	a.alerts = append(a.alerts, alert)
+	return a.err
+}
+
+func TestNewAlerters(t *testing.T) {
+	alerters, err := NewAlerters(&util.AlertConfig{
+		Moniker:         "moniker",
+		TelegramBotId:   "bot_id",
+		SlackWebhookUrl: "http://slack",
+		WebhookUrl:      "http://webhook",
+		SmtpHost:        "smtp",
+		SmtpPort:        25,
+		SmtpFrom:        "relayer@example.com",
+		SmtpTo:          []string{"oncall@example.com"},
+	})
+	require.Nil(t, err)
+
+	names := make([]string, 0, len(alerters))
+	for _, alerter := range alerters {
+		names = append(names, alerter.Name())
+	}
+	// telegram alerter needs both bot id and chat id
+	require.Equal(t, []string{"slack", "webhook", "smtp"}, names)
+}
+
+func TestSend(t *testing.T) {
+	Init(&util.AlertConfig{})
+
+	failed := &recordAlerter{err: errors.New("send error")}
+	succeeded := &recordAlerter{}
+	Register(failed)
+	Register(succeeded)
+	defer Init(&util.AlertConfig{})
+
+	Send("dedup_key", util.SeverityCritical, "msg")
+	Send("dedup_key", util.SeverityCritical, "")
+
+	require.Len(t, failed.alerts, 1)
+	require.Len(t, succeeded.alerts, 1)
+	require.Equal(t, &Alert{DedupKey: "dedup_key", Severity: util.SeverityCritical, Message: "msg"}, succeeded.alerts[0])
+}
+
+func TestSlackAlerter_Send(t *testing.T) {
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bz, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(bz, &body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := NewSlackAlerter(server.URL).Send(&Alert{DedupKey: "dedup_key", Message: "msg"})
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"text": "msg"}, body)
+}
+
+func TestWebhookAlerter_Send(t *testing.T) {
+	var message WebhookMessage
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Token")
+		bz, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(bz, &message)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	alerter := NewWebhookAlerter("moniker", server.URL, map[string]string{"X-Token": "token"})
+	err := alerter.Send(&Alert{DedupKey: "dedup_key", Message: "msg"})
+	require.Nil(t, err)
+	require.Equal(t, "token", header)
+	require.Equal(t, "moniker", message.Moniker)
+	require.Equal(t, "dedup_key", message.DedupKey)
+	require.Equal(t, "msg", message.Message)
+}
+
+func TestWebhookAlerter_Send_errorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewWebhookAlerter("moniker", server.URL, nil).Send(&Alert{Message: "msg"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "status=401")
+}
+
+func TestSmtpAlerter_buildMail(t *testing.T) {
+	alerter := NewSmtpAlerter("smtp", 25, "", "", "relayer@example.com", []string{"a@example.com", "b@example.com"})
+	mail := string(alerter.buildMail(&Alert{Message: "summary\ndetails"}))
+
+	require.True(t, strings.HasPrefix(mail, "From: relayer@example.com\r\nTo: a@example.com, b@example.com\r\nSubject: summary\r\n"))
+	require.True(t, strings.HasSuffix(mail, "\r\n\r\nsummary\ndetails"))
+}
+
+func TestSmtpAlerter_Send_timeout(t *testing.T) {
+	smtpTimeout = 100 * time.Millisecond
+	defer func() { smtpTimeout = 10 * time.Second }()
+
+	// the smtp server accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	alerter := NewSmtpAlerter("127.0.0.1", addr.Port, "", "", "relayer@example.com", []string{"a@example.com"})
+	start := time.Now()
+	require.NotNil(t, alerter.Send(&Alert{Message: "msg"}))
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPagerDutyAlerter_buildEvent(t *testing.T) {
+	cfg := &util.AlertConfig{
+		Moniker:                    "moniker",
+		BlockUpdateTimeOut:         10,
+		PackageDelayAlertThreshold: 10,
+		PagerDutyAuthToken:         "token",
+		PagerDutySummary:           "{{.Moniker}} {{.Severity}}: {{.Message}}",
+		PagerDutyGroup:             "bridge",
+	}
+	require.Nil(t, cfg.Validate())
+
+	alerter, err := NewPagerDutyAlerter(cfg)
+	require.Nil(t, err)
+	event := alerter.buildEvent(&Alert{
+		DedupKey: "dedup_key",
+		Severity: util.SeverityWarning,
+		Message:  "msg",
+	})
+	require.Equal(t, "trigger", event.Action)
+	require.Equal(t, "dedup_key", event.DedupKey)
+	require.Equal(t, "moniker warning: msg", event.Payload.Summary)
+	require.Equal(t, util.SeverityWarning, event.Payload.Severity)
+	require.Equal(t, "moniker", event.Payload.Source)
+	require.Equal(t, util.DefaultPagerDutyComponent, event.Payload.Component)
+	require.Equal(t, "bridge", event.Payload.Group)
+	require.Equal(t, util.DefaultPagerDutyClass, event.Payload.Class)
+
+	event = alerter.buildEvent(&Alert{DedupKey: "dedup_key", Message: "msg"})
+	require.Equal(t, util.SeverityError, event.Payload.Severity)
+
+	cfg.PagerDutySummary = "{{.Moniker"
+	_, err = NewPagerDutyAlerter(cfg)
+	require.NotNil(t, err)
+	_, err = NewAlerters(cfg)
+	require.NotNil(t, err)
+}
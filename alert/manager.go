@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+)
+
+var defaultManager = NewManager(common.DefaultAlertRepeatInterval, send)
+
+// alertState is the state of the condition of one dedup key
+type alertState struct {
+	firing   bool
+	severity string
+	lastSent time.Time
+}
+
+// Manager tracks the state of every condition by dedup key, alerts are sent only when the state changes or the
+// repeat interval passes since the last alert of a condition which still holds
+type Manager struct {
+	mtx sync.Mutex
+
+	repeatInterval time.Duration
+	states         map[string]*alertState
+
+	send func(alert *Alert)
+	now  func() time.Time
+}
+
+// NewManager returns the alert manager which sends alerts by the given function
+func NewManager(repeatInterval time.Duration, send func(alert *Alert)) *Manager {
+	return &Manager{
+		repeatInterval: repeatInterval,
+		states:         make(map[string]*alertState),
+		send:           send,
+		now:            time.Now,
+	}
+}
+
+// SetRepeatInterval sets the interval to repeat alerts of conditions which still hold, DefaultAlertRepeatInterval
+// is used if it is not larger than 0
+func (m *Manager) SetRepeatInterval(repeatInterval time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if repeatInterval <= 0 {
+		repeatInterval = common.DefaultAlertRepeatInterval
+	}
+	m.repeatInterval = repeatInterval
+}
+
+// Fire sends the alert if the condition of the dedup key was not firing, its severity changes, or the last alert
+// of it was sent the repeat interval ago
+func (m *Manager) Fire(dedupKey string, severity string, msg string) {
+	m.mtx.Lock()
+	state, ok := m.states[dedupKey]
+	if !ok {
+		state = &alertState{}
+		m.states[dedupKey] = state
+	}
+	now := m.now()
+	if state.firing && state.severity == severity && now.Sub(state.lastSent) < m.repeatInterval {
+		m.mtx.Unlock()
+		return
+	}
+	state.firing = true
+	state.severity = severity
+	state.lastSent = now
+	m.mtx.Unlock()
+
+	m.send(&Alert{
+		DedupKey: dedupKey,
+		Severity: severity,
+		Message:  msg,
+	})
+}
+
+// Resolve sends the recovery message if the condition of the dedup key was firing
+func (m *Manager) Resolve(dedupKey string, msg string) {
+	m.mtx.Lock()
+	state, ok := m.states[dedupKey]
+	if !ok || !state.firing {
+		m.mtx.Unlock()
+		return
+	}
+	state.firing = false
+	state.lastSent = m.now()
+	severity := state.severity
+	m.mtx.Unlock()
+
+	m.send(&Alert{
+		DedupKey: dedupKey,
+		Severity: severity,
+		Message:  msg,
+		Resolved: true,
+	})
+}
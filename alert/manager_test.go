@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestManager(t *testing.T) {
+	sent := make([]*Alert, 0)
+	manager := NewManager(time.Minute, func(alert *Alert) {
+		sent = append(sent, alert)
+	})
+	now := time.Unix(1600000000, 0)
+	manager.now = func() time.Time {
+		return now
+	}
+
+	// resolving a condition which is not firing sends nothing
+	manager.Resolve("key", "resolved")
+	require.Len(t, sent, 0)
+
+	manager.Fire("key", util.SeverityError, "msg")
+	require.Len(t, sent, 1)
+	require.Equal(t, &Alert{DedupKey: "key", Severity: util.SeverityError, Message: "msg"}, sent[0])
+
+	// alerts are throttled until the repeat interval passes
+	now = now.Add(30 * time.Second)
+	manager.Fire("key", util.SeverityError, "msg")
+	require.Len(t, sent, 1)
+
+	// conditions of other dedup keys are tracked separately
+	manager.Fire("other_key", util.SeverityError, "msg")
+	require.Len(t, sent, 2)
+
+	now = now.Add(31 * time.Second)
+	manager.Fire("key", util.SeverityError, "msg")
+	require.Len(t, sent, 3)
+
+	manager.Resolve("key", "resolved")
+	require.Len(t, sent, 4)
+	require.Equal(t, &Alert{DedupKey: "key", Severity: util.SeverityError, Message: "resolved", Resolved: true}, sent[3])
+
+	manager.Resolve("key", "resolved")
+	require.Len(t, sent, 4)
+
+	// the condition is alerted at once if it holds again
+	manager.Fire("key", util.SeverityWarning, "msg")
+	require.Len(t, sent, 5)
+
+	// the condition is alerted at once if it is escalated
+	now = now.Add(time.Second)
+	manager.Fire("key", util.SeverityCritical, "msg")
+	require.Len(t, sent, 6)
+	require.Equal(t, util.SeverityCritical, sent[5].Severity)
+}
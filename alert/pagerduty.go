@@ -0,0 +1,110 @@
+package alert
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/PagerDuty/go-pagerduty"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// pagerDutyMaxSummaryLength is the max length of incident summaries accepted by PagerDuty
+const pagerDutyMaxSummaryLength = 1024
+
+// summaryData is the data to render the summary template with
+type summaryData struct {
+	Moniker  string
+	DedupKey string
+	Severity string
+	Message  string
+}
+
+// PagerDutyAlerter triggers PagerDuty incidents through the events api v2
+type PagerDutyAlerter struct {
+	AuthToken string
+	Moniker   string
+	Source    string
+	Component string
+	Group     string
+	Class     string
+
+	summary *template.Template
+}
+
+// NewPagerDutyAlerter returns the PagerDuty alerter of the alert config, error is returned if pager_duty_summary is
+// not a valid template
+func NewPagerDutyAlerter(cfg *util.AlertConfig) (*PagerDutyAlerter, error) {
+	summary, err := template.New("summary").Parse(cfg.PagerDutySummary)
+	if err != nil {
+		return nil, fmt.Errorf("parse pager_duty_summary error, err=%s", err.Error())
+	}
+	return &PagerDutyAlerter{
+		AuthToken: cfg.PagerDutyAuthToken,
+		Moniker:   cfg.Moniker,
+		Source:    cfg.PagerDutySource,
+		Component: cfg.PagerDutyComponent,
+		Group:     cfg.PagerDutyGroup,
+		Class:     cfg.PagerDutyClass,
+		summary:   summary,
+	}, nil
+}
+
+func (a *PagerDutyAlerter) Name() string {
+	return "pager_duty"
+}
+
+// Send triggers the incident of the dedup key, or resolves it if the alert is resolved
+func (a *PagerDutyAlerter) Send(alert *Alert) error {
+	if alert.Resolved {
+		_, err := pagerduty.ManageEvent(pagerduty.V2Event{
+			RoutingKey: a.AuthToken,
+			Action:     "resolve",
+			DedupKey:   alert.DedupKey,
+		})
+		return err
+	}
+
+	_, err := pagerduty.ManageEvent(a.buildEvent(alert))
+	return err
+}
+
+func (a *PagerDutyAlerter) buildEvent(alert *Alert) pagerduty.V2Event {
+	severity := alert.Severity
+	if severity == "" {
+		severity = util.SeverityError
+	}
+
+	var summary bytes.Buffer
+	err := a.summary.Execute(&summary, &summaryData{
+		Moniker:  a.Moniker,
+		DedupKey: alert.DedupKey,
+		Severity: severity,
+		Message:  alert.Message,
+	})
+	if err != nil {
+		util.Logger.Errorf("render pager duty summary error, err=%s", err.Error())
+		summary.Reset()
+		summary.WriteString(alert.Message)
+	}
+	summaryStr := summary.String()
+	if len(summaryStr) > pagerDutyMaxSummaryLength {
+		summaryStr = summaryStr[:pagerDutyMaxSummaryLength]
+	}
+
+	return pagerduty.V2Event{
+		RoutingKey: a.AuthToken,
+		Action:     "trigger",
+		DedupKey:   alert.DedupKey,
+		Payload: &pagerduty.V2Payload{
+			Summary:   summaryStr,
+			Source:    a.Source,
+			Severity:  severity,
+			Component: a.Component,
+			Group:     a.Group,
+			Class:     a.Class,
+			Details:   alert.Message,
+		},
+	}
+}
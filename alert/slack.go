@@ -0,0 +1,22 @@
+package alert
+
+// SlackAlerter sends alerts to a slack channel through an incoming webhook
+type SlackAlerter struct {
+	WebhookUrl string
+}
+
+func NewSlackAlerter(webhookUrl string) *SlackAlerter {
+	return &SlackAlerter{
+		WebhookUrl: webhookUrl,
+	}
+}
+
+func (a *SlackAlerter) Name() string {
+	return "slack"
+}
+
+func (a *SlackAlerter) Send(alert *Alert) error {
+	return postJSON(a.WebhookUrl, nil, map[string]string{
+		"text": alert.Message,
+	})
+}
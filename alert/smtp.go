@@ -0,0 +1,115 @@
+package alert
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpTimeout is the timeout of the whole smtp session of one alert
+var smtpTimeout = 10 * time.Second
+
+// SmtpAlerter sends alerts by email through a smtp server
+type SmtpAlerter struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func NewSmtpAlerter(host string, port int, username, password, from string, to []string) *SmtpAlerter {
+	return &SmtpAlerter{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+		To:       to,
+	}
+}
+
+func (a *SmtpAlerter) Name() string {
+	return "smtp"
+}
+
+func (a *SmtpAlerter) Send(alert *Alert) error {
+	var auth smtp.Auth
+	if a.Username != "" {
+		auth = smtp.PlainAuth("", a.Username, a.Password, a.Host)
+	}
+	addr := net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+	return sendMail(addr, a.Host, auth, a.From, a.To, a.buildMail(alert))
+}
+
+// sendMail sends the mail as smtp.SendMail does, but the whole session should finish in smtpTimeout, so that a hung
+// smtp server does not block the alerts
+func sendMail(addr, host string, auth smtp.Auth, from string, to []string, mail []byte) error {
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(mail); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMail returns the mail of the alert, the subject is the first line of the message
+func (a *SmtpAlerter) buildMail(alert *Alert) []byte {
+	subject := strings.SplitN(alert.Message, "\n", 2)[0]
+	lines := []string{
+		fmt.Sprintf("From: %s", a.From),
+		fmt.Sprintf("To: %s", strings.Join(a.To, ", ")),
+		fmt.Sprintf("Subject: %s", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		alert.Message,
+	}
+	return []byte(strings.Join(lines, "\r\n"))
+}
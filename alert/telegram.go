@@ -0,0 +1,54 @@
+package alert
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+const telegramApiUrl = "https://api.telegram.org"
+
+// TelegramAlerter sends alerts to a telegram group through a bot
+type TelegramAlerter struct {
+	BotId  string
+	ChatId string
+
+	apiUrl string
+}
+
+func NewTelegramAlerter(botId, chatId string) *TelegramAlerter {
+	return &TelegramAlerter{
+		BotId:  botId,
+		ChatId: chatId,
+		apiUrl: telegramApiUrl,
+	}
+}
+
+func (a *TelegramAlerter) Name() string {
+	return "telegram"
+}
+
+// Send sends message to telegram group
+func (a *TelegramAlerter) Send(alert *Alert) error {
+	endPoint := fmt.Sprintf("%s/bot%s/sendMessage", a.apiUrl, a.BotId)
+	formData := url.Values{
+		"chat_id":    {a.ChatId},
+		"parse_mode": {"html"},
+		"text":       {alert.Message},
+	}
+	util.Logger.Infof("send tg message, chat_id=%s, msg=%s", a.ChatId, alert.Message)
+	res, err := httpClient.PostForm(endPoint, formData)
+	if err != nil {
+		return fmt.Errorf("send telegram message error, chat_id=%s, err=%s", a.ChatId, err.Error())
+	}
+	defer res.Body.Close()
+
+	bodyBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("read http response error, err=%s", err.Error())
+	}
+	util.Logger.Infof("tg response: %s", string(bodyBytes))
+	return nil
+}
@@ -0,0 +1,45 @@
+package alert
+
+import (
+	"time"
+)
+
+// WebhookMessage is the json body posted to the generic webhook
+type WebhookMessage struct {
+	Moniker  string `json:"moniker"`
+	DedupKey string `json:"dedup_key"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Resolved bool   `json:"resolved"`
+	Time     int64  `json:"time"`
+}
+
+// WebhookAlerter posts alerts as json to a generic webhook
+type WebhookAlerter struct {
+	Moniker string
+	Url     string
+	Headers map[string]string
+}
+
+func NewWebhookAlerter(moniker, url string, headers map[string]string) *WebhookAlerter {
+	return &WebhookAlerter{
+		Moniker: moniker,
+		Url:     url,
+		Headers: headers,
+	}
+}
+
+func (a *WebhookAlerter) Name() string {
+	return "webhook"
+}
+
+func (a *WebhookAlerter) Send(alert *Alert) error {
+	return postJSON(a.Url, a.Headers, &WebhookMessage{
+		Moniker:  a.Moniker,
+		DedupKey: alert.DedupKey,
+		Severity: alert.Severity,
+		Message:  alert.Message,
+		Resolved: alert.Resolved,
+		Time:     time.Now().Unix(),
+	})
+}
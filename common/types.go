@@ -3,14 +3,51 @@ package common
 import "time"
 
 const (
-	ObserverMaxBlockNumber = 10000
-	ObserverPruneInterval  = 10 * time.Second
-	ObserverAlertInterval  = 5 * time.Second
-	ObserverFetchInterval  = 2 * time.Second
+	ObserverMaxBlockNumber  = 10000
+	ObserverPruneInterval   = 10 * time.Second
+	ObserverAlertInterval   = 5 * time.Second
+	ObserverFetchInterval   = 2 * time.Second
+	ObserverMetricsInterval = 10 * time.Second
+
+	// ObserverSubscribeFetchInterval is the max interval between fetches when blocks are subscribed, in case
+	// that any event is missed
+	ObserverSubscribeFetchInterval = 30 * time.Second
+	ObserverResubscribeInterval    = 10 * time.Second
+
+	// ObserverReorgSearchWindow is the number of blocks whose headers are fetched at once when searching for
+	// the common ancestor of a reorg
+	ObserverReorgSearchWindow int64 = 100
 
 	PackageDelayAlertInterval = 5 * time.Second
 
+	// DefaultAlertRepeatInterval is the interval to repeat alerts of conditions which still hold
+	DefaultAlertRepeatInterval = 30 * time.Minute
+
+	AscHeaderBatchSize int64 = 100
+
 	DefaultConfirmNum int64 = 15
+
+	RecoveryMaxBlockRange int64 = 5000
+
+	ProviderCheckInterval = 10 * time.Second
+	ProviderMaxAttempts   = 3
+	// ProviderErrorWindow is the number of the latest calls of a provider to calculate the error rate with
+	ProviderErrorWindow  = 20
+	ProviderMinCalls     = 5
+	ProviderMaxErrorRate = 0.5
+
+	AscProviderMaxHeightLag int64 = 20
+	AfcProviderMaxHeightLag int64 = 5
+
+	ClaimTxTrackInterval = 5 * time.Second
+	// ClaimTxDropTimeout is the time after which a claim tx broadcast in sync mode is taken as dropped if it is
+	// not included in any block
+	ClaimTxDropTimeout int64 = 120
+
+	// GapDetectInterval is the interval to check the sequences of all the packages in database for gaps
+	GapDetectInterval = time.Minute
+	// BackfillMaxSequences is the max number of missing sequences of a gap searched in ASC at once
+	BackfillMaxSequences uint64 = 100
 )
 
 const (
@@ -18,6 +55,20 @@ const (
 	DBDialectSqlite3 = "sqlite3"
 )
 
+type BlockHeader struct {
+	Height          int64
+	BlockHash       string
+	ParentBlockHash string
+	BlockTime       int64
+}
+
+// TxResult is the result of the Axim Chain tx included in a block, the tx failed if code is not 0
+type TxResult struct {
+	Height int64
+	Code   uint32
+	Log    string
+}
+
 type BlockAndPackageLogs struct {
 	Height          int64
 	BlockHash       string
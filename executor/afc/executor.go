@@ -1,33 +1,144 @@
 package afc
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
-	"time"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
 
 	"github.com/aximchain/go-sdk/client/rpc"
 	"github.com/aximchain/go-sdk/common/types"
 	"github.com/aximchain/go-sdk/keys"
 	"github.com/aximchain/go-sdk/types/msg"
+	"github.com/aximchain/go-sdk/types/tx"
 
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/pool"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
 
+const (
+	// receiveSequenceStorePath is the store of the cross-chain sequences of Axim Chain
+	receiveSequenceStorePath = "/store/sc/key"
+	// receiveSequenceKeyPrefix prefixes the keys of the next package sequences to receive, the key is followed by
+	// the big endian source chain id and the channel id
+	receiveSequenceKeyPrefix = 0xf1
+)
+
 type Executor struct {
-	config     *util.Config
-	RpcClients []rpc.Client
+	config  *util.Config
+	network types.ChainNetwork
+	Pool    *pool.Pool
+
+	// mtx guards the clients, which are replaced when the providers are reloaded
+	mtx        sync.RWMutex
+	rpcClients []rpc.Client
+
+	// claimMtx serializes the claims of all the chains, since they are signed by the same account
+	claimMtx sync.Mutex
+	// the account number and sequence are managed locally in sync mode, so that claims can be broadcast before
+	// the previous ones are included in blocks. They are fetched from Axim Chain again if accountLoaded is false.
+	accountLoaded   bool
+	accountNumber   int64
+	accountSequence int64
+
+	// keyMtx guards the key manager, which is loaded once and replaced when the key is rotated
+	keyMtx     sync.RWMutex
+	keyManager keys.KeyManager
 }
 
-// NewExecutor returns the AFC executor instance
+// NewExecutor returns the AFC executor instance, it fails if the key can not be loaded
 func NewExecutor(providers []string, network types.ChainNetwork, cfg *util.Config) (*Executor, error) {
-	return &Executor{
+	e := &Executor{
 		config:     cfg,
-		RpcClients: initClients(providers, network),
-	}, nil
+		network:    network,
+		Pool:       pool.NewPool("afc", providers, common.AfcProviderMaxHeightLag),
+		rpcClients: initClients(providers, network),
+	}
+	if _, err := e.RotateKey(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RotateKey loads the key from the key source of config again, eg(the aws secret, the keystore file or the remote
+// signer), and uses it for the following claims. It returns the address of the new key, the key in use is kept if
+// the new one can not be loaded.
+func (e *Executor) RotateKey() (types.ValAddress, error) {
+	keyManager, err := getKeyManager(e.config.ChainConfig)
+	if err != nil {
+		return nil, fmt.Errorf("load afc key error, err=%s", err.Error())
+	}
+	address := types.ValAddress(keyManager.GetAddr())
+
+	e.keyMtx.Lock()
+	defer e.keyMtx.Unlock()
+
+	if e.keyManager != nil {
+		util.Logger.Infof("afc key rotated, old_address=%s, new_address=%s",
+			types.ValAddress(e.keyManager.GetAddr()).String(), address.String())
+	}
+	e.keyManager = keyManager
+	e.ResetAccountSequence()
+	return address, nil
+}
+
+// ResetAccountSequence makes the next claim fetch the account sequence from Axim Chain, it should be called if any
+// claim tx broadcast in sync mode is dropped
+func (e *Executor) ResetAccountSequence() {
+	e.claimMtx.Lock()
+	defer e.claimMtx.Unlock()
+
+	e.accountLoaded = false
+}
+
+// loadAccount fetches the account number and sequence of the given address, claimMtx should be held
+func (e *Executor) loadAccount(addr types.AccAddress) error {
+	return e.Pool.Call(func(idx int) error {
+		client, err := e.getClient(idx)
+		if err != nil {
+			return err
+		}
+		account, err := client.GetAccount(addr)
+		if err != nil {
+			return err
+		}
+		if account == nil {
+			return fmt.Errorf("account %s not found", addr.String())
+		}
+		e.accountNumber = account.GetAccountNumber()
+		e.accountSequence = account.GetSequence()
+		e.accountLoaded = true
+		return nil
+	})
+}
+
+func (e *Executor) getKeyManager() (keys.KeyManager, error) {
+	e.keyMtx.RLock()
+	defer e.keyMtx.RUnlock()
+
+	if e.keyManager == nil {
+		return nil, fmt.Errorf("afc key is not loaded")
+	}
+	return e.keyManager, nil
 }
 
 // getKeyManager returns the key manager from config
 func getKeyManager(config *util.ChainConfig) (keys.KeyManager, error) {
+	if config.AFCKeyType == util.KeyTypeRemoteSigner {
+		return NewRemoteSignerKeyManager(config.AFCRemoteSignerUrl, config.AFCRemoteSignerAuthToken)
+	}
+	if config.AFCKeyType == util.KeyTypeKeystore {
+		password, err := getKeystorePassword(config)
+		if err != nil {
+			return nil, err
+		}
+		return keys.NewKeyStoreKeyManager(config.AFCKeystoreFile, password)
+	}
+
 	var axcMnemonic string
 	if config.AFCKeyType == util.KeyTypeAWSMnemonic {
 		awsMnemonic, err := util.GetSecret(config.AFCAWSSecretName, config.AFCAWSRegion)
@@ -42,6 +153,23 @@ func getKeyManager(config *util.ChainConfig) (keys.KeyManager, error) {
 	return keys.NewMnemonicKeyManager(axcMnemonic)
 }
 
+// getKeystorePassword returns the password of the keystore file from the password file or the env var
+func getKeystorePassword(config *util.ChainConfig) (string, error) {
+	if config.AFCKeystorePasswordFile != "" {
+		bz, err := ioutil.ReadFile(config.AFCKeystorePasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("read keystore password file error, err=%s", err.Error())
+		}
+		return strings.TrimRight(string(bz), "\r\n"), nil
+	}
+
+	password, ok := os.LookupEnv(config.AFCKeystorePasswordEnv)
+	if !ok {
+		return "", fmt.Errorf("env var %s of keystore password is not set", config.AFCKeystorePasswordEnv)
+	}
+	return password, nil
+}
+
 func initClients(providers []string, network types.ChainNetwork) []rpc.Client {
 	clients := make([]rpc.Client, 0)
 
@@ -53,56 +181,208 @@ func initClients(providers []string, network types.ChainNetwork) []rpc.Client {
 	return clients
 }
 
-func (e *Executor) getClient() rpc.Client {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+// SetProviders replaces the providers of the executor
+func (e *Executor) SetProviders(providers []string) {
+	rpcClients := initClients(providers, e.network)
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.rpcClients = rpcClients
+	e.Pool.SetProviders(providers)
+}
+
+// getClient returns the client of the provider of the given index
+func (e *Executor) getClient(idx int) (rpc.Client, error) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	if idx >= len(e.rpcClients) {
+		return nil, fmt.Errorf("provider %d not found, providers are reloaded", idx)
+	}
+	return e.rpcClients[idx], nil
+}
+
+// StartHealthCheck checks the head height of every provider periodically, unhealthy or lagging providers are
+// removed from rotation
+func (e *Executor) StartHealthCheck() {
+	e.Pool.Start(e.getHeadHeight)
+}
 
-	idx := r.Intn(len(e.RpcClients))
-	return e.RpcClients[idx]
+func (e *Executor) getHeadHeight(idx int) (int64, error) {
+	client, err := e.getClient(idx)
+	if err != nil {
+		return 0, err
+	}
+	status, err := client.Status()
+	if err != nil {
+		return 0, err
+	}
+	return status.SyncInfo.LatestBlockHeight, nil
 }
 
 // GetAddress returns validator address of the oracle relayer
-func (e *Executor) GetAddress() types.ValAddress {
-	keyManager, err := getKeyManager(e.config.ChainConfig)
+func (e *Executor) GetAddress() (types.ValAddress, error) {
+	keyManager, err := e.getKeyManager()
 	if err != nil {
-		return types.ValAddress{}
+		return nil, err
 	}
-	return types.ValAddress(keyManager.GetAddr())
+	return types.ValAddress(keyManager.GetAddr()), nil
 }
 
 // GetProphecy returns the prophecy of the given sequence
 func (e *Executor) GetProphecy(chainId uint16, sequence int64) (*msg.Prophecy, error) {
-	prop, err := e.getClient().GetProphecy(types.IbcChainID(chainId), sequence)
+	var prop *msg.Prophecy
+	err := e.Pool.Call(func(idx int) error {
+		client, err := e.getClient(idx)
+		if err != nil {
+			return err
+		}
+		prop, err = client.GetProphecy(types.IbcChainID(chainId), sequence)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return prop, err
+	return prop, nil
 }
 
 // Claim sends claim to Axim Chain
 func (e *Executor) Claim(chainId uint16, sequence uint64, payload []byte) (string, error) {
-	client := e.getClient()
-
-	keyManager, err := getKeyManager(e.config.ChainConfig)
+	keyManager, err := e.getKeyManager()
 	if err != nil {
-		return "", fmt.Errorf("get key manager error, err=%s", err.Error())
+		return "", err
 	}
-	client.SetKeyManager(keyManager)
-	defer client.SetKeyManager(nil)
 
-	res, err := client.Claim(types.IbcChainID(chainId), sequence, payload, rpc.Commit)
+	e.claimMtx.Lock()
+	defer e.claimMtx.Unlock()
+
+	syncType := rpc.Commit
+	options := make([]tx.Option, 0)
+	if e.config.ChainConfig.AFCBroadcastMode == util.BroadcastModeSync {
+		syncType = rpc.Sync
+		if !e.accountLoaded {
+			if err := e.loadAccount(keyManager.GetAddr()); err != nil {
+				return "", fmt.Errorf("get account error, err=%s", err.Error())
+			}
+		}
+		options = append(options, tx.WithAcNumAndSequence(e.accountNumber, e.accountSequence))
+	}
+
+	var txHash string
+	err = e.Pool.Call(func(idx int) error {
+		client, err := e.getClient(idx)
+		if err != nil {
+			return err
+		}
+		client.SetKeyManager(keyManager)
+		defer client.SetKeyManager(nil)
+
+		res, err := client.Claim(types.IbcChainID(chainId), sequence, payload, syncType, options...)
+		if err != nil {
+			// the claim tx may have been broadcast already in any broadcast mode, retrying it on another provider
+			// with the same account sequence would fail the check of mempool while the first one is still pending,
+			// the sequence is fetched again by the next claim
+			return pool.Permanent(err)
+		}
+		// the claim is rejected by Axim Chain, trying another provider will not help
+		if res.Code != 0 {
+			return pool.Permanent(fmt.Errorf("claim error, code=%d, log=%s", res.Code, res.Log))
+		}
+		txHash = res.Hash.String()
+		return nil
+	})
 	if err != nil {
+		// the account sequence is not consumed if the claim tx is not accepted
+		e.accountLoaded = false
 		return "", err
 	}
-	if res.Code != 0 {
-		return "", fmt.Errorf("claim error, code=%d, log=%s", res.Code, res.Log)
+	e.accountSequence++
+	util.Logger.Infof("claim success, tx_hash=%s, broadcast_mode=%s", txHash, e.config.ChainConfig.AFCBroadcastMode)
+	return txHash, nil
+}
+
+// GetTx returns the result of the tx of the given hash, nil is returned if the tx is not included in any block
+func (e *Executor) GetTx(txHash string) (*common.TxResult, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hash %s", txHash)
+	}
+
+	var result *common.TxResult
+	err = e.Pool.Call(func(idx int) error {
+		client, err := e.getClient(idx)
+		if err != nil {
+			return err
+		}
+		// the tx search returns no tx instead of an error if the tx is not in any block yet
+		res, err := client.TxSearch(fmt.Sprintf("tx.hash='%X'", hash), false, 1, 1)
+		if err != nil {
+			return err
+		}
+		if len(res.Txs) == 0 {
+			result = nil
+			return nil
+		}
+		result = &common.TxResult{
+			Height: res.Txs[0].Height,
+			Code:   res.Txs[0].TxResult.Code,
+			Log:    res.Txs[0].TxResult.Log,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	util.Logger.Infof("claim success, tx_hash=%s", res.Hash.String())
-	return res.Hash.String(), nil
+	return result, nil
 }
 
 // GetCurrentSequence return the current oracle sequence of Axim Chain
 func (e *Executor) GetCurrentSequence(chainId uint16) (int64, error) {
-	sequence, err := e.getClient().GetCurrentOracleSequence(types.IbcChainID(chainId))
+	var sequence int64
+	err := e.Pool.Call(func(idx int) error {
+		client, err := e.getClient(idx)
+		if err != nil {
+			return err
+		}
+		sequence, err = client.GetCurrentOracleSequence(types.IbcChainID(chainId))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sequence, nil
+}
+
+// GetReceiveSequence returns the next package sequence of the given channel that Axim Chain expects to receive, the
+// packages of lower sequences are accepted already
+func (e *Executor) GetReceiveSequence(chainId uint16, channelId uint8) (uint64, error) {
+	key := []byte{receiveSequenceKeyPrefix, byte(chainId >> 8), byte(chainId), channelId}
+
+	var sequence uint64
+	err := e.Pool.Call(func(idx int) error {
+		client, err := e.getClient(idx)
+		if err != nil {
+			return err
+		}
+		res, err := client.ABCIQuery(receiveSequenceStorePath, key)
+		if err != nil {
+			return err
+		}
+		if !res.Response.IsOK() {
+			return fmt.Errorf("query receive sequence error, code=%d, log=%s", res.Response.Code, res.Response.Log)
+		}
+		// nothing is received from the channel yet
+		if len(res.Response.Value) == 0 {
+			sequence = 0
+			return nil
+		}
+		if len(res.Response.Value) != 8 {
+			return pool.Permanent(fmt.Errorf("invalid receive sequence, value=%x", res.Response.Value))
+		}
+		sequence = binary.BigEndian.Uint64(res.Response.Value)
+		return nil
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,56 @@
+package afc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aximchain/go-sdk/keys"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestGetKeyManager_keystore(t *testing.T) {
+	keyManager, err := keys.NewKeyManager()
+	require.Nil(t, err)
+	keystore, err := keyManager.ExportAsKeyStore("password")
+	require.Nil(t, err)
+	keystoreBytes, err := json.Marshal(keystore)
+	require.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "keystore")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	keystoreFile := filepath.Join(dir, "keystore.json")
+	require.Nil(t, ioutil.WriteFile(keystoreFile, keystoreBytes, 0600))
+	passwordFile := filepath.Join(dir, "password")
+	require.Nil(t, ioutil.WriteFile(passwordFile, []byte("password\n"), 0600))
+
+	config := &util.ChainConfig{
+		AFCKeyType:              util.KeyTypeKeystore,
+		AFCKeystoreFile:         keystoreFile,
+		AFCKeystorePasswordFile: passwordFile,
+	}
+	loaded, err := getKeyManager(config)
+	require.Nil(t, err)
+	require.Equal(t, keyManager.GetAddr(), loaded.GetAddr())
+
+	config.AFCKeystorePasswordFile = ""
+	config.AFCKeystorePasswordEnv = "TEST_AFC_KEYSTORE_PASSWORD"
+	_, err = getKeyManager(config)
+	require.NotNil(t, err)
+
+	os.Setenv(config.AFCKeystorePasswordEnv, "password")
+	defer os.Unsetenv(config.AFCKeystorePasswordEnv)
+	loaded, err = getKeyManager(config)
+	require.Nil(t, err)
+	require.Equal(t, keyManager.GetAddr(), loaded.GetAddr())
+
+	os.Setenv(config.AFCKeystorePasswordEnv, "wrong password")
+	_, err = getKeyManager(config)
+	require.NotNil(t, err)
+}
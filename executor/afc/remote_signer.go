@@ -0,0 +1,148 @@
+package afc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/aximchain/go-sdk/keys"
+	"github.com/aximchain/go-sdk/types/tx"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+const remoteSignerTimeout = 10 * time.Second
+
+// PubKeyResponse is the response of GET /pubkey of the remote signer
+type PubKeyResponse struct {
+	// PubKey is the hex of the compressed secp256k1 public key
+	PubKey string `json:"pub_key"`
+}
+
+// SignRequest is the request of POST /sign of the remote signer
+type SignRequest struct {
+	// SignBytes are the bytes of the std sign msg to sign
+	SignBytes []byte `json:"sign_bytes"`
+}
+
+// SignResponse is the response of POST /sign of the remote signer
+type SignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// RemoteSignerKeyManager implements keys.KeyManager by sending sign requests to a remote signer over http, so that
+// the key never enters the relayer process. The signer serves GET /pubkey and POST /sign, and signatures are
+// verified by the public key before they are used.
+type RemoteSignerKeyManager struct {
+	url       string
+	authToken string
+	client    *http.Client
+
+	pubKey secp256k1.PubKeySecp256k1
+}
+
+var _ keys.KeyManager = (*RemoteSignerKeyManager)(nil)
+
+// NewRemoteSignerKeyManager returns the key manager of the remote signer, the public key is fetched at once so
+// that an unreachable signer fails fast
+func NewRemoteSignerKeyManager(url string, authToken string) (*RemoteSignerKeyManager, error) {
+	m := &RemoteSignerKeyManager{
+		url:       strings.TrimRight(url, "/"),
+		authToken: authToken,
+		client:    &http.Client{Timeout: remoteSignerTimeout},
+	}
+
+	res := &PubKeyResponse{}
+	if err := m.do(http.MethodGet, "/pubkey", nil, res); err != nil {
+		return nil, fmt.Errorf("get pubkey from remote signer error, err=%s", err.Error())
+	}
+	pubKeyBytes, err := hex.DecodeString(res.PubKey)
+	if err != nil || len(pubKeyBytes) != len(m.pubKey) {
+		return nil, fmt.Errorf("invalid pubkey from remote signer, pub_key=%s", res.PubKey)
+	}
+	copy(m.pubKey[:], pubKeyBytes)
+	return m, nil
+}
+
+func (m *RemoteSignerKeyManager) do(method string, path string, reqBody interface{}, resBody interface{}) error {
+	var body []byte
+	if reqBody != nil {
+		var err error
+		body, err = json.Marshal(reqBody)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, m.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+m.authToken)
+	}
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("read http response error, err=%s", err.Error())
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected http status, status=%d, body=%s", res.StatusCode, string(resBytes))
+	}
+	return json.Unmarshal(resBytes, resBody)
+}
+
+// Sign signs the msg by the remote signer and returns the signed tx
+func (m *RemoteSignerKeyManager) Sign(msg tx.StdSignMsg) ([]byte, error) {
+	signBytes := msg.Bytes()
+	res := &SignResponse{}
+	if err := m.do(http.MethodPost, "/sign", &SignRequest{SignBytes: signBytes}, res); err != nil {
+		return nil, fmt.Errorf("sign by remote signer error, err=%s", err.Error())
+	}
+	if !m.pubKey.VerifyBytes(signBytes, res.Signature) {
+		return nil, fmt.Errorf("invalid signature from remote signer")
+	}
+
+	sig := tx.StdSignature{
+		AccountNumber: msg.AccountNumber,
+		Sequence:      msg.Sequence,
+		PubKey:        m.pubKey,
+		Signature:     res.Signature,
+	}
+	newTx := tx.NewStdTx(msg.Msgs, []tx.StdSignature{sig}, msg.Memo, msg.Source, msg.Data)
+	return tx.Cdc.MarshalBinaryLengthPrefixed(&newTx)
+}
+
+// GetPrivKey returns nil since the private key is held by the remote signer
+func (m *RemoteSignerKeyManager) GetPrivKey() crypto.PrivKey {
+	return nil
+}
+
+func (m *RemoteSignerKeyManager) GetAddr() types.AccAddress {
+	return types.AccAddress(m.pubKey.Address())
+}
+
+func (m *RemoteSignerKeyManager) ExportAsMnemonic() (string, error) {
+	return "", fmt.Errorf("the key of remote signer can not be exported")
+}
+
+func (m *RemoteSignerKeyManager) ExportAsPrivateKey() (string, error) {
+	return "", fmt.Errorf("the key of remote signer can not be exported")
+}
+
+func (m *RemoteSignerKeyManager) ExportAsKeyStore(password string) (*keys.EncryptedKeyJSON, error) {
+	return nil, fmt.Errorf("the key of remote signer can not be exported")
+}
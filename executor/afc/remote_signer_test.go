@@ -0,0 +1,128 @@
+package afc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/aximchain/go-sdk/keys"
+	"github.com/aximchain/go-sdk/types/tx"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// newTestSigner returns a stand-in remote signer holding the given key
+func newTestSigner(t *testing.T, privKey secp256k1.PrivKeySecp256k1, authToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+authToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/pubkey":
+			pubKey := privKey.PubKey().(secp256k1.PubKeySecp256k1)
+			if err := json.NewEncoder(w).Encode(&PubKeyResponse{PubKey: hex.EncodeToString(pubKey[:])}); err != nil {
+				t.Errorf("encode pubkey response error, err=%s", err.Error())
+			}
+		case "/sign":
+			req := &SignRequest{}
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			signature, err := privKey.Sign(req.SignBytes)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err := json.NewEncoder(w).Encode(&SignResponse{Signature: signature}); err != nil {
+				t.Errorf("encode sign response error, err=%s", err.Error())
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRemoteSignerKeyManager(t *testing.T) {
+	privKey := secp256k1.GenPrivKey()
+	signer := newTestSigner(t, privKey, "token")
+	defer signer.Close()
+
+	localKeyManager, err := keys.NewPrivateKeyManager(hex.EncodeToString(privKey[:]))
+	require.Nil(t, err)
+
+	keyManager, err := NewRemoteSignerKeyManager(signer.URL, "token")
+	require.Nil(t, err)
+	require.Equal(t, localKeyManager.GetAddr(), keyManager.GetAddr())
+
+	signMsg := tx.StdSignMsg{
+		ChainID:       "test-chain",
+		AccountNumber: 1,
+		Sequence:      2,
+		Memo:          "memo",
+	}
+	signed, err := keyManager.Sign(signMsg)
+	require.Nil(t, err)
+	expected, err := localKeyManager.Sign(signMsg)
+	require.Nil(t, err)
+	require.Equal(t, expected, signed)
+
+	_, err = NewRemoteSignerKeyManager(signer.URL, "wrong token")
+	require.NotNil(t, err)
+}
+
+func TestRemoteSignerKeyManager_invalidSignature(t *testing.T) {
+	signer := newTestSigner(t, secp256k1.GenPrivKey(), "")
+	defer signer.Close()
+
+	keyManager, err := NewRemoteSignerKeyManager(signer.URL, "")
+	require.Nil(t, err)
+	// signatures of another key are rejected
+	keyManager.pubKey = secp256k1.GenPrivKey().PubKey().(secp256k1.PubKeySecp256k1)
+
+	_, err = keyManager.Sign(tx.StdSignMsg{ChainID: "test-chain"})
+	require.NotNil(t, err)
+}
+
+func TestExecutor_RotateKey_remoteSigner(t *testing.T) {
+	privKey := secp256k1.GenPrivKey()
+	signer := newTestSigner(t, privKey, "")
+	defer signer.Close()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.AFCKeyType = util.KeyTypeRemoteSigner
+	config.ChainConfig.AFCRemoteSignerUrl = signer.URL
+
+	e := &Executor{config: config}
+	_, err := e.GetAddress()
+	require.NotNil(t, err, "the key is not loaded")
+
+	address, err := e.RotateKey()
+	require.Nil(t, err)
+	require.Equal(t, types.ValAddress(privKey.PubKey().Address()), address)
+
+	// the key in use is kept if the new one can not be loaded
+	newPrivKey := secp256k1.GenPrivKey()
+	newSigner := newTestSigner(t, newPrivKey, "token")
+	defer newSigner.Close()
+	config.ChainConfig.AFCRemoteSignerUrl = newSigner.URL
+	_, err = e.RotateKey()
+	require.NotNil(t, err)
+	address, err = e.GetAddress()
+	require.Nil(t, err)
+	require.Equal(t, types.ValAddress(privKey.PubKey().Address()), address)
+
+	config.ChainConfig.AFCRemoteSignerAuthToken = "token"
+	_, err = e.RotateKey()
+	require.Nil(t, err)
+	address, err = e.GetAddress()
+	require.Nil(t, err)
+	require.Equal(t, types.ValAddress(newPrivKey.PubKey().Address()), address)
+}
@@ -2,113 +2,528 @@ package asc
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	ethcmm "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/Sotatek-huytran2/oracle-relayer/common"
 	abi2 "github.com/Sotatek-huytran2/oracle-relayer/executor/asc/abi"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/pool"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
 
 type Executor struct {
-	Config *util.Config
+	Config      *util.Config
+	ChainConfig *util.ChainConfig
 
 	CrossChainAbi abi.ABI
-	Clients       []*ethclient.Client
+	Pool          *pool.Pool
+
+	// mtx guards the clients, which are replaced when the providers are reloaded
+	mtx        sync.RWMutex
+	clients    []*ethclient.Client
+	rpcClients []*rpc.Client
 
 	crossChainContractAddress ethcmm.Address
 }
 
-// NewExecutor returns the asc executor instance
-func NewExecutor(providers []string, config *util.Config) *Executor {
+// NewExecutor returns the asc executor instance of the given source chain
+func NewExecutor(chainConfig *util.ChainConfig, config *util.Config) *Executor {
 	crossChainAbi, err := abi.JSON(strings.NewReader(abi2.CrossChainABI))
 	if err != nil {
 		panic("marshal abi error")
 	}
 
-	clients := initClients(providers)
+	clients, rpcClients := initClients(chainConfig.ASCProviders)
 
 	return &Executor{
 		Config:        config,
+		ChainConfig:   chainConfig,
 		CrossChainAbi: crossChainAbi,
-		Clients:       clients,
+		Pool: pool.NewPool(fmt.Sprintf("asc_%d", chainConfig.ASCChainId), chainConfig.ASCProviders,
+			common.AscProviderMaxHeightLag),
+		clients:    clients,
+		rpcClients: rpcClients,
 
-		crossChainContractAddress: config.ChainConfig.ASCCrossChainContractAddress,
+		crossChainContractAddress: chainConfig.ASCCrossChainContractAddress,
 	}
 }
 
-func initClients(providers []string) []*ethclient.Client {
+func initClients(providers []string) ([]*ethclient.Client, []*rpc.Client) {
 	clients := make([]*ethclient.Client, 0)
+	rpcClients := make([]*rpc.Client, 0)
 
 	for _, provider := range providers {
-		client, err := ethclient.Dial(provider)
+		rpcClient, err := rpc.Dial(provider)
 		if err != nil {
 			panic("new eth client error")
 		}
-		clients = append(clients, client)
+		clients = append(clients, ethclient.NewClient(rpcClient))
+		rpcClients = append(rpcClients, rpcClient)
 	}
 
-	return clients
+	return clients, rpcClients
 }
 
-func (e *Executor) getClient() *ethclient.Client {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+// SetProviders replaces the providers of the executor, the clients of the new providers are dialed first so
+// that the providers are not changed if any of them fails
+func (e *Executor) SetProviders(providers []string) error {
+	clients := make([]*ethclient.Client, 0, len(providers))
+	rpcClients := make([]*rpc.Client, 0, len(providers))
+	for _, provider := range providers {
+		rpcClient, err := rpc.Dial(provider)
+		if err != nil {
+			return fmt.Errorf("dial provider error, provider=%s, err=%s", provider, err.Error())
+		}
+		clients = append(clients, ethclient.NewClient(rpcClient))
+		rpcClients = append(rpcClients, rpcClient)
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
 
-	idx := r.Intn(len(e.Clients))
-	return e.Clients[idx]
+	e.clients = clients
+	e.rpcClients = rpcClients
+	e.Pool.SetProviders(providers)
+	return nil
 }
 
-// GetBlockAndPackages returns the block and cross-chain packages of the given height
-func (e *Executor) GetBlockAndPackages(height int64) (*common.BlockAndPackageLogs, error) {
+// getClients returns the clients of the provider of the given index
+func (e *Executor) getClients(idx int) (*ethclient.Client, *rpc.Client, error) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	if idx >= len(e.clients) {
+		return nil, nil, fmt.Errorf("provider %d not found, providers are reloaded", idx)
+	}
+	return e.clients[idx], e.rpcClients[idx], nil
+}
+
+// StartHealthCheck checks the head height of every provider periodically, unhealthy or lagging providers are
+// removed from rotation
+func (e *Executor) StartHealthCheck() {
+	e.Pool.Start(e.getHeadHeight)
+}
+
+func (e *Executor) getHeadHeight(idx int) (int64, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	client := e.getClient()
-	header, err := client.HeaderByNumber(ctxWithTimeout, big.NewInt(height))
+	client, _, err := e.getClients(idx)
+	if err != nil {
+		return 0, err
+	}
+	header, err := client.HeaderByNumber(ctxWithTimeout, nil)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Int64(), nil
+}
+
+// GetBlockAndPackages returns the block and cross-chain packages of the given height
+func (e *Executor) GetBlockAndPackages(height int64) (*common.BlockAndPackageLogs, error) {
+	var blockAndPackageLogs *common.BlockAndPackageLogs
+	err := e.Pool.Call(func(idx int) error {
+		ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, _, err := e.getClients(idx)
+		if err != nil {
+			return err
+		}
+		header, err := client.HeaderByNumber(ctxWithTimeout, big.NewInt(height))
+		if err != nil {
+			return err
+		}
+
+		blockHash := header.Hash()
+		packageLogs, err := e.GetLogs(client, ethereum.FilterQuery{BlockHash: &blockHash})
+		if err != nil {
+			return err
+		}
+
+		blockAndPackageLogs = &common.BlockAndPackageLogs{
+			Height:          height,
+			BlockHash:       header.Hash().String(),
+			ParentBlockHash: header.ParentHash.String(),
+			BlockTime:       int64(header.Time),
+			Packages:        packageLogs,
+		}
+		return nil
+	})
+	return blockAndPackageLogs, err
+}
+
+// GetBlocksAndPackages returns the blocks and cross-chain packages between the given heights, both inclusive.
+// The headers are fetched in json-rpc batches and the packages are fetched by one filter query.
+func (e *Executor) GetBlocksAndPackages(fromHeight, toHeight int64) ([]*common.BlockAndPackageLogs, error) {
+	if toHeight < fromHeight {
+		return nil, fmt.Errorf("invalid block range, from=%d, to=%d", fromHeight, toHeight)
+	}
+
+	var headers []*types.Header
+	var packageLogs []interface{}
+	err := e.Pool.Call(func(idx int) error {
+		client, rpcClient, err := e.getClients(idx)
+		if err != nil {
+			return err
+		}
+		headers, err = getHeadersInBatches(rpcClient, fromHeight, toHeight)
+		if err != nil {
+			return err
+		}
+
+		packageLogs, err = e.GetLogs(client, ethereum.FilterQuery{
+			FromBlock: big.NewInt(fromHeight),
+			ToBlock:   big.NewInt(toHeight),
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	packageLogs, err := e.GetLogs(client, header)
+	blocks := make([]*common.BlockAndPackageLogs, 0, len(headers))
+	for _, header := range headers {
+		blocks = append(blocks, &common.BlockAndPackageLogs{
+			Height:          header.Number.Int64(),
+			BlockHash:       header.Hash().String(),
+			ParentBlockHash: header.ParentHash.String(),
+			BlockTime:       int64(header.Time),
+			Packages:        make([]interface{}, 0),
+		})
+	}
+
+	for _, pack := range toPackages(packageLogs) {
+		block := blocks[pack.Height-fromHeight]
+		// the logs and the headers are not fetched atomically, so they may be from different forks
+		if pack.BlockHash != block.BlockHash {
+			return nil, fmt.Errorf("block hash of package mismatch, height=%d, block_hash=%s, package_block_hash=%s",
+				pack.Height, block.BlockHash, pack.BlockHash)
+		}
+		block.Packages = append(block.Packages, pack)
+	}
+	return blocks, nil
+}
+
+// GetBlockHeaders returns the block headers between the given heights, both inclusive
+func (e *Executor) GetBlockHeaders(fromHeight, toHeight int64) ([]*common.BlockHeader, error) {
+	if toHeight < fromHeight {
+		return nil, fmt.Errorf("invalid block range, from=%d, to=%d", fromHeight, toHeight)
+	}
+
+	var headers []*types.Header
+	err := e.Pool.Call(func(idx int) error {
+		_, rpcClient, err := e.getClients(idx)
+		if err != nil {
+			return err
+		}
+		headers, err = getHeadersInBatches(rpcClient, fromHeight, toHeight)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &common.BlockAndPackageLogs{
-		Height:          height,
-		BlockHash:       header.Hash().String(),
-		ParentBlockHash: header.ParentHash.String(),
-		BlockTime:       int64(header.Time),
-		Packages:        packageLogs,
-	}, nil
+	blockHeaders := make([]*common.BlockHeader, 0, len(headers))
+	for _, header := range headers {
+		blockHeaders = append(blockHeaders, &common.BlockHeader{
+			Height:          header.Number.Int64(),
+			BlockHash:       header.Hash().String(),
+			ParentBlockHash: header.ParentHash.String(),
+			BlockTime:       int64(header.Time),
+		})
+	}
+	return blockHeaders, nil
 }
 
-// GetLogs return the cross-chain packages of the given height
-func (e *Executor) GetLogs(client *ethclient.Client, header *types.Header) ([]interface{}, error) {
-	topics := [][]ethcmm.Hash{{CrossChainPackageEventHash}}
+// getHeadersInBatches returns the block headers between the given heights in json-rpc batches of AscHeaderBatchSize
+func getHeadersInBatches(rpcClient *rpc.Client, fromHeight, toHeight int64) ([]*types.Header, error) {
+	headers := make([]*types.Header, 0, toHeight-fromHeight+1)
+	for batchStart := fromHeight; batchStart <= toHeight; batchStart += common.AscHeaderBatchSize {
+		batchEnd := batchStart + common.AscHeaderBatchSize - 1
+		if batchEnd > toHeight {
+			batchEnd = toHeight
+		}
 
-	blockHash := header.Hash()
+		batchHeaders, err := getHeaders(rpcClient, batchStart, batchEnd)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, batchHeaders...)
+	}
+	return headers, nil
+}
 
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// getHeaders returns the block headers between the given heights in one json-rpc batch
+func getHeaders(rpcClient *rpc.Client, fromHeight, toHeight int64) ([]*types.Header, error) {
+	headers := make([]*types.Header, toHeight-fromHeight+1)
+	batch := make([]rpc.BatchElem, 0, len(headers))
+	for i := range headers {
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeBig(big.NewInt(fromHeight + int64(i))), false},
+			Result: &headers[i],
+		})
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	logs, err := client.FilterLogs(ctxWithTimeout, ethereum.FilterQuery{
-		BlockHash: &blockHash,
-		Topics:    topics,
-		Addresses: []ethcmm.Address{e.crossChainContractAddress},
+	if err := rpcClient.BatchCallContext(ctxWithTimeout, batch); err != nil {
+		return nil, err
+	}
+
+	for i, elem := range batch {
+		height := fromHeight + int64(i)
+		if elem.Error != nil {
+			return nil, fmt.Errorf("get block header error, height=%d, err=%s", height, elem.Error.Error())
+		}
+		if headers[i] == nil {
+			return nil, fmt.Errorf("block not found, height=%d", height)
+		}
+	}
+	return headers, nil
+}
+
+// GetLatestHeight returns the height of the latest block of ASC
+func (e *Executor) GetLatestHeight() (int64, error) {
+	var height int64
+	err := e.Pool.Call(func(idx int) error {
+		var err error
+		height, err = e.getHeadHeight(idx)
+		return err
+	})
+	return height, err
+}
+
+// GetFinalizedHeight returns the height of the block with the given finality tag, eg(finalized or safe)
+func (e *Executor) GetFinalizedHeight(tag string) (int64, error) {
+	var header *types.Header
+	err := e.Pool.Call(func(idx int) error {
+		ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, rpcClient, err := e.getClients(idx)
+		if err != nil {
+			return err
+		}
+		err = rpcClient.CallContext(ctxWithTimeout, &header, "eth_getBlockByNumber", tag, false)
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return fmt.Errorf("%s block not found", tag)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Int64(), nil
+}
+
+// GetPackagesByTx returns the cross-chain packages emitted by the given tx
+func (e *Executor) GetPackagesByTx(txHash string) ([]*model.CrossChainPackageLog, error) {
+	var receipt *types.Receipt
+	var packageLogs []interface{}
+	err := e.Pool.Call(func(idx int) error {
+		ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, _, err := e.getClients(idx)
+		if err != nil {
+			return err
+		}
+		receipt, err = client.TransactionReceipt(ctxWithTimeout, ethcmm.HexToHash(txHash))
+		if err != nil {
+			return fmt.Errorf("get tx receipt error, tx_hash=%s, err=%s", txHash, err.Error())
+		}
+
+		blockHash := receipt.BlockHash
+		packageLogs, err = e.GetLogs(client, ethereum.FilterQuery{BlockHash: &blockHash})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make([]*model.CrossChainPackageLog, 0)
+	for _, packageLog := range toPackages(packageLogs) {
+		if packageLog.TxHash == receipt.TxHash.String() {
+			packages = append(packages, packageLog)
+		}
+	}
+	return packages, nil
+}
+
+// GetPackagesByRange returns the cross-chain packages emitted between the given heights, both inclusive
+func (e *Executor) GetPackagesByRange(fromHeight, toHeight int64) ([]*model.CrossChainPackageLog, error) {
+	var packageLogs []interface{}
+	err := e.Pool.Call(func(idx int) error {
+		client, _, err := e.getClients(idx)
+		if err != nil {
+			return err
+		}
+		packageLogs, err = e.GetLogs(client, ethereum.FilterQuery{
+			FromBlock: big.NewInt(fromHeight),
+			ToBlock:   big.NewInt(toHeight),
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
+	return toPackages(packageLogs), nil
+}
 
+// GetPackagesBySequences returns the cross-chain packages emitted between the given heights, both inclusive, which
+// match the given oracle sequences, package sequences and channel ids by the indexed topics of the event. An empty
+// list matches any value. The range is searched in chunks of RecoveryMaxBlockRange blocks.
+func (e *Executor) GetPackagesBySequences(fromHeight, toHeight int64, oracleSequences, packageSequences []uint64,
+	channelIds []uint8) ([]*model.CrossChainPackageLog, error) {
+	if toHeight < fromHeight {
+		return nil, fmt.Errorf("invalid block range, from=%d, to=%d", fromHeight, toHeight)
+	}
+
+	channelTopics := make([]ethcmm.Hash, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		channelTopics = append(channelTopics, ethcmm.BigToHash(big.NewInt(int64(channelId))))
+	}
+	topics := [][]ethcmm.Hash{{CrossChainPackageEventHash}, toTopics(oracleSequences), toTopics(packageSequences), channelTopics}
+
+	packages := make([]*model.CrossChainPackageLog, 0)
+	for chunkStart := fromHeight; chunkStart <= toHeight; chunkStart += common.RecoveryMaxBlockRange {
+		chunkEnd := chunkStart + common.RecoveryMaxBlockRange - 1
+		if chunkEnd > toHeight {
+			chunkEnd = toHeight
+		}
+
+		var packageLogs []interface{}
+		err := e.Pool.Call(func(idx int) error {
+			client, _, err := e.getClients(idx)
+			if err != nil {
+				return err
+			}
+			packageLogs, err = e.GetLogs(client, ethereum.FilterQuery{
+				FromBlock: big.NewInt(chunkStart),
+				ToBlock:   big.NewInt(chunkEnd),
+				Topics:    topics,
+			})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, toPackages(packageLogs)...)
+	}
+	return packages, nil
+}
+
+// toTopics returns the indexed topics of the given sequences
+func toTopics(sequences []uint64) []ethcmm.Hash {
+	topics := make([]ethcmm.Hash, 0, len(sequences))
+	for _, sequence := range sequences {
+		topics = append(topics, ethcmm.BigToHash(new(big.Int).SetUint64(sequence)))
+	}
+	return topics
+}
+
+func toPackages(packageLogs []interface{}) []*model.CrossChainPackageLog {
+	packages := make([]*model.CrossChainPackageLog, 0, len(packageLogs))
+	for _, packageLog := range packageLogs {
+		if pack, ok := packageLog.(*model.CrossChainPackageLog); ok {
+			packages = append(packages, pack)
+		}
+	}
+	return packages
+}
+
+// SubscribeBlocks subscribes to the new heads and the cross-chain package logs of ASC through a websocket provider,
+// and notifies the sink of the height of each event without blocking. It returns when the subscription fails.
+func (e *Executor) SubscribeBlocks(ctx context.Context, sink chan<- int64) error {
+	providers := e.ChainConfig.ASCWsProviders
+	if len(providers) == 0 {
+		return fmt.Errorf("asc_ws_providers is empty")
+	}
+	provider := providers[rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(providers))]
+
+	client, err := ethclient.DialContext(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("dial websocket provider error, err=%s", err.Error())
+	}
+	defer client.Close()
+
+	headCh := make(chan *types.Header)
+	headSub, err := client.SubscribeNewHead(ctx, headCh)
+	if err != nil {
+		return fmt.Errorf("subscribe new heads error, err=%s", err.Error())
+	}
+	defer headSub.Unsubscribe()
+
+	logCh := make(chan types.Log)
+	logSub, err := client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []ethcmm.Address{e.crossChainContractAddress},
+		Topics:    [][]ethcmm.Hash{{CrossChainPackageEventHash}},
+	}, logCh)
+	if err != nil {
+		return fmt.Errorf("subscribe cross-chain package logs error, err=%s", err.Error())
+	}
+	defer logSub.Unsubscribe()
+
+	util.Logger.Infof("subscribed to new blocks of asc, chain_id=%d", e.ChainConfig.ASCChainId)
+	notify := func(height int64) {
+		select {
+		case sink <- height:
+		default:
+		}
+	}
+
+	for {
+		select {
+		case header := <-headCh:
+			notify(header.Number.Int64())
+		case log := <-logCh:
+			notify(int64(log.BlockNumber))
+		case err := <-headSub.Err():
+			return fmt.Errorf("new heads subscription dropped, err=%v", err)
+		case err := <-logSub.Err():
+			return fmt.Errorf("cross-chain package logs subscription dropped, err=%v", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// GetLogs returns the cross-chain packages matching the given filter query. The contract address and the
+// first topic of the query are always set to the cross-chain contract and the cross-chain package event.
+func (e *Executor) GetLogs(client *ethclient.Client, query ethereum.FilterQuery) ([]interface{}, error) {
+	topics := [][]ethcmm.Hash{{CrossChainPackageEventHash}}
+	if len(query.Topics) > 1 {
+		topics = append(topics, query.Topics[1:]...)
+	}
+	query.Topics = topics
+	query.Addresses = []ethcmm.Address{e.crossChainContractAddress}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logs, err := client.FilterLogs(ctxWithTimeout, query)
+	if err != nil {
+		return nil, err
+	}
 	packageModels := make([]interface{}, 0, len(logs))
 
 	for _, log := range logs {
@@ -1,20 +1,35 @@
 package executor
 
 import (
+	"context"
+
 	"github.com/aximchain/go-sdk/common/types"
 	"github.com/aximchain/go-sdk/types/msg"
 
 	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
 )
 
 type AfcExecutor interface {
-	GetAddress() types.ValAddress
+	GetAddress() (types.ValAddress, error)
 	GetCurrentSequence(chainId uint16) (int64, error)
+	GetReceiveSequence(chainId uint16, channelId uint8) (uint64, error)
 	GetProphecy(chainId uint16, sequence int64) (*msg.Prophecy, error)
 
 	Claim(chainId uint16, sequence uint64, payload []byte) (string, error)
+	GetTx(txHash string) (*common.TxResult, error)
+	ResetAccountSequence()
 }
 
 type AscExecutor interface {
 	GetBlockAndPackages(height int64) (*common.BlockAndPackageLogs, error)
+	GetBlocksAndPackages(fromHeight, toHeight int64) ([]*common.BlockAndPackageLogs, error)
+	GetBlockHeaders(fromHeight, toHeight int64) ([]*common.BlockHeader, error)
+	GetLatestHeight() (int64, error)
+	GetFinalizedHeight(tag string) (int64, error)
+	GetPackagesByTx(txHash string) ([]*model.CrossChainPackageLog, error)
+	GetPackagesByRange(fromHeight, toHeight int64) ([]*model.CrossChainPackageLog, error)
+	GetPackagesBySequences(fromHeight, toHeight int64, oracleSequences, packageSequences []uint64,
+		channelIds []uint8) ([]*model.CrossChainPackageLog, error)
+	SubscribeBlocks(ctx context.Context, sink chan<- int64) error
 }
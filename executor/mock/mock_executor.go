@@ -5,11 +5,13 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
 	types "github.com/aximchain/go-sdk/common/types"
 	msg "github.com/aximchain/go-sdk/types/msg"
 	common "github.com/Sotatek-huytran2/oracle-relayer/common"
+	model "github.com/Sotatek-huytran2/oracle-relayer/model"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -37,11 +39,12 @@ func (m *MockAfcExecutor) EXPECT() *MockAfcExecutorMockRecorder {
 }
 
 // GetAddress mocks base method
-func (m *MockAfcExecutor) GetAddress() types.ValAddress {
+func (m *MockAfcExecutor) GetAddress() (types.ValAddress, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetAddress")
 	ret0, _ := ret[0].(types.ValAddress)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetAddress indicates an expected call of GetAddress
@@ -65,6 +68,21 @@ func (mr *MockAfcExecutorMockRecorder) GetCurrentSequence(chainId interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentSequence", reflect.TypeOf((*MockAfcExecutor)(nil).GetCurrentSequence), chainId)
 }
 
+// GetReceiveSequence mocks base method
+func (m *MockAfcExecutor) GetReceiveSequence(chainId uint16, channelId uint8) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReceiveSequence", chainId, channelId)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReceiveSequence indicates an expected call of GetReceiveSequence
+func (mr *MockAfcExecutorMockRecorder) GetReceiveSequence(chainId, channelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReceiveSequence", reflect.TypeOf((*MockAfcExecutor)(nil).GetReceiveSequence), chainId, channelId)
+}
+
 // GetProphecy mocks base method
 func (m *MockAfcExecutor) GetProphecy(chainId uint16, sequence int64) (*msg.Prophecy, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +113,33 @@ func (mr *MockAfcExecutorMockRecorder) Claim(chainId, sequence, payload interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockAfcExecutor)(nil).Claim), chainId, sequence, payload)
 }
 
+// GetTx mocks base method
+func (m *MockAfcExecutor) GetTx(txHash string) (*common.TxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTx", txHash)
+	ret0, _ := ret[0].(*common.TxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTx indicates an expected call of GetTx
+func (mr *MockAfcExecutorMockRecorder) GetTx(txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTx", reflect.TypeOf((*MockAfcExecutor)(nil).GetTx), txHash)
+}
+
+// ResetAccountSequence mocks base method
+func (m *MockAfcExecutor) ResetAccountSequence() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetAccountSequence")
+}
+
+// ResetAccountSequence indicates an expected call of ResetAccountSequence
+func (mr *MockAfcExecutorMockRecorder) ResetAccountSequence() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetAccountSequence", reflect.TypeOf((*MockAfcExecutor)(nil).ResetAccountSequence))
+}
+
 // MockAscExecutor is a mock of AscExecutor interface
 type MockAscExecutor struct {
 	ctrl     *gomock.Controller
@@ -132,3 +177,122 @@ func (mr *MockAscExecutorMockRecorder) GetBlockAndPackages(height interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockAndPackages", reflect.TypeOf((*MockAscExecutor)(nil).GetBlockAndPackages), height)
 }
+
+// GetLatestHeight mocks base method
+func (m *MockAscExecutor) GetLatestHeight() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestHeight")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestHeight indicates an expected call of GetLatestHeight
+func (mr *MockAscExecutorMockRecorder) GetLatestHeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestHeight", reflect.TypeOf((*MockAscExecutor)(nil).GetLatestHeight))
+}
+
+// GetPackagesByTx mocks base method
+func (m *MockAscExecutor) GetPackagesByTx(txHash string) ([]*model.CrossChainPackageLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPackagesByTx", txHash)
+	ret0, _ := ret[0].([]*model.CrossChainPackageLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPackagesByTx indicates an expected call of GetPackagesByTx
+func (mr *MockAscExecutorMockRecorder) GetPackagesByTx(txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPackagesByTx", reflect.TypeOf((*MockAscExecutor)(nil).GetPackagesByTx), txHash)
+}
+
+// GetPackagesByRange mocks base method
+func (m *MockAscExecutor) GetPackagesByRange(fromHeight, toHeight int64) ([]*model.CrossChainPackageLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPackagesByRange", fromHeight, toHeight)
+	ret0, _ := ret[0].([]*model.CrossChainPackageLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPackagesByRange indicates an expected call of GetPackagesByRange
+func (mr *MockAscExecutorMockRecorder) GetPackagesByRange(fromHeight, toHeight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPackagesByRange", reflect.TypeOf((*MockAscExecutor)(nil).GetPackagesByRange), fromHeight, toHeight)
+}
+
+// GetPackagesBySequences mocks base method
+func (m *MockAscExecutor) GetPackagesBySequences(fromHeight, toHeight int64, oracleSequences, packageSequences []uint64, channelIds []uint8) ([]*model.CrossChainPackageLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPackagesBySequences", fromHeight, toHeight, oracleSequences, packageSequences, channelIds)
+	ret0, _ := ret[0].([]*model.CrossChainPackageLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPackagesBySequences indicates an expected call of GetPackagesBySequences
+func (mr *MockAscExecutorMockRecorder) GetPackagesBySequences(fromHeight, toHeight, oracleSequences, packageSequences, channelIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPackagesBySequences", reflect.TypeOf((*MockAscExecutor)(nil).GetPackagesBySequences), fromHeight, toHeight, oracleSequences, packageSequences, channelIds)
+}
+
+// GetBlocksAndPackages mocks base method
+func (m *MockAscExecutor) GetBlocksAndPackages(fromHeight, toHeight int64) ([]*common.BlockAndPackageLogs, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlocksAndPackages", fromHeight, toHeight)
+	ret0, _ := ret[0].([]*common.BlockAndPackageLogs)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlocksAndPackages indicates an expected call of GetBlocksAndPackages
+func (mr *MockAscExecutorMockRecorder) GetBlocksAndPackages(fromHeight, toHeight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlocksAndPackages", reflect.TypeOf((*MockAscExecutor)(nil).GetBlocksAndPackages), fromHeight, toHeight)
+}
+
+// SubscribeBlocks mocks base method
+func (m *MockAscExecutor) SubscribeBlocks(ctx context.Context, sink chan<- int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeBlocks", ctx, sink)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubscribeBlocks indicates an expected call of SubscribeBlocks
+func (mr *MockAscExecutorMockRecorder) SubscribeBlocks(ctx, sink interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeBlocks", reflect.TypeOf((*MockAscExecutor)(nil).SubscribeBlocks), ctx, sink)
+}
+
+// GetBlockHeaders mocks base method
+func (m *MockAscExecutor) GetBlockHeaders(fromHeight, toHeight int64) ([]*common.BlockHeader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockHeaders", fromHeight, toHeight)
+	ret0, _ := ret[0].([]*common.BlockHeader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockHeaders indicates an expected call of GetBlockHeaders
+func (mr *MockAscExecutorMockRecorder) GetBlockHeaders(fromHeight, toHeight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockHeaders", reflect.TypeOf((*MockAscExecutor)(nil).GetBlockHeaders), fromHeight, toHeight)
+}
+
+// GetFinalizedHeight mocks base method
+func (m *MockAscExecutor) GetFinalizedHeight(tag string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFinalizedHeight", tag)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFinalizedHeight indicates an expected call of GetFinalizedHeight
+func (mr *MockAscExecutorMockRecorder) GetFinalizedHeight(tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFinalizedHeight", reflect.TypeOf((*MockAscExecutor)(nil).GetFinalizedHeight), tag)
+}
@@ -0,0 +1,327 @@
+package pool
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// latencyWeight is the weight of the latest call in the moving average of the latency
+const latencyWeight = 0.2
+
+// HeadFunc returns the head height of the provider of the given index
+type HeadFunc func(idx int) (int64, error)
+
+// permanentError is the error which is not caused by the provider, so it is neither retried nor counted
+// as a provider failure
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Permanent marks the error as not caused by the provider, Call returns it without retrying on other providers
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// ProviderState is the health state of one provider
+type ProviderState struct {
+	Url        string  `json:"url"`
+	Healthy    bool    `json:"healthy"`
+	Height     int64   `json:"height"`
+	HeightLag  int64   `json:"height_lag"`
+	LatencyMs  float64 `json:"latency_ms"`
+	Calls      int64   `json:"calls"`
+	Errors     int64   `json:"errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	LastError  string  `json:"last_error"`
+	UpdateTime int64   `json:"update_time"`
+}
+
+type provider struct {
+	url     string
+	healthy bool
+	height  int64
+	latency time.Duration
+	calls   int64
+	errors  int64
+	lastErr string
+	// results are the results of the latest calls, true for failed calls
+	results    []bool
+	updateTime int64
+}
+
+func (p *provider) errorRate() float64 {
+	if len(p.results) == 0 {
+		return 0
+	}
+	failed := 0
+	for _, result := range p.results {
+		if result {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(p.results))
+}
+
+func (p *provider) record(latency time.Duration, err error) {
+	p.calls++
+	p.updateTime = time.Now().Unix()
+
+	p.results = append(p.results, err != nil)
+	if len(p.results) > common.ProviderErrorWindow {
+		p.results = p.results[len(p.results)-common.ProviderErrorWindow:]
+	}
+
+	if err != nil {
+		p.errors++
+		p.lastErr = err.Error()
+		return
+	}
+	if p.latency == 0 {
+		p.latency = latency
+	} else {
+		p.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(p.latency))
+	}
+}
+
+// Pool tracks the latency, error rate and head height of providers, and calls the healthy ones
+type Pool struct {
+	mtx sync.RWMutex
+
+	Name         string
+	maxHeightLag int64
+	providers    []*provider
+}
+
+// NewPool returns the pool of the given providers, providers lagging behind the highest one by more than
+// maxHeightLag are removed from rotation
+func NewPool(name string, urls []string, maxHeightLag int64) *Pool {
+	providers := make([]*provider, 0, len(urls))
+	for _, url := range urls {
+		providers = append(providers, &provider{url: url, healthy: true})
+	}
+	return &Pool{
+		Name:         name,
+		maxHeightLag: maxHeightLag,
+		providers:    providers,
+	}
+}
+
+// SetProviders replaces the providers of the pool, the states of the providers which are kept are kept as well
+func (p *Pool) SetProviders(urls []string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	existing := make(map[string]*provider, len(p.providers))
+	for _, prov := range p.providers {
+		existing[prov.url] = prov
+	}
+
+	providers := make([]*provider, 0, len(urls))
+	for _, url := range urls {
+		if prov, ok := existing[url]; ok {
+			providers = append(providers, prov)
+		} else {
+			providers = append(providers, &provider{url: url, healthy: true})
+		}
+	}
+	p.providers = providers
+}
+
+// Size returns the number of providers in the pool
+func (p *Pool) Size() int {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	return len(p.providers)
+}
+
+// candidates returns the indexes of the healthy providers in random order. If no provider is healthy,
+// all the providers are returned since failing for sure is worse.
+func (p *Pool) candidates() []int {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	healthy := make([]int, 0, len(p.providers))
+	all := make([]int, 0, len(p.providers))
+	for idx, prov := range p.providers {
+		if prov.healthy {
+			healthy = append(healthy, idx)
+		}
+		all = append(all, idx)
+	}
+	if len(healthy) == 0 {
+		healthy = all
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r.Shuffle(len(healthy), func(i, j int) {
+		healthy[i], healthy[j] = healthy[j], healthy[i]
+	})
+	return healthy
+}
+
+// Call calls fn with the index of a healthy provider, and retries on another provider if it fails, up to
+// ProviderMaxAttempts times. Errors marked by Permanent are returned at once.
+func (p *Pool) Call(fn func(idx int) error) error {
+	var err error
+	for attempt, idx := range p.candidates() {
+		if attempt >= common.ProviderMaxAttempts {
+			break
+		}
+
+		start := time.Now()
+		err = fn(idx)
+		if permErr, ok := err.(*permanentError); ok {
+			p.record(idx, time.Since(start), nil)
+			return permErr.err
+		}
+		p.record(idx, time.Since(start), err)
+		if err == nil {
+			return nil
+		}
+		util.Logger.Errorf("call provider error, pool=%s, provider=%s, err=%s", p.Name, p.url(idx), err.Error())
+	}
+	return err
+}
+
+func (p *Pool) url(idx int) string {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	if idx >= len(p.providers) {
+		return ""
+	}
+	return p.providers[idx].url
+}
+
+func (p *Pool) record(idx int, latency time.Duration, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	// the providers may be replaced during the call
+	if idx >= len(p.providers) {
+		return
+	}
+	prov := p.providers[idx]
+	prov.record(latency, err)
+	if prov.healthy && len(prov.results) >= common.ProviderMinCalls && prov.errorRate() > common.ProviderMaxErrorRate {
+		prov.healthy = false
+		util.Logger.Errorf("provider removed from rotation, pool=%s, provider=%s, error_rate=%.2f",
+			p.Name, prov.url, prov.errorRate())
+	}
+}
+
+// Start checks the head height of every provider every ProviderCheckInterval
+func (p *Pool) Start(headFn HeadFunc) {
+	for {
+		p.Check(headFn)
+		time.Sleep(common.ProviderCheckInterval)
+	}
+}
+
+// Check fetches the head height of every provider and updates whether they are healthy. A provider is unhealthy
+// if its head can not be fetched, it lags behind the highest provider or too many of its latest calls failed.
+// An unhealthy provider gets another chance once its head is fetched and it catches up.
+func (p *Pool) Check(headFn HeadFunc) {
+	p.mtx.RLock()
+	providers := p.providers
+	p.mtx.RUnlock()
+
+	heights := make([]int64, len(providers))
+	errs := make([]error, len(providers))
+	latencies := make([]time.Duration, len(providers))
+
+	var wg sync.WaitGroup
+	for idx := range providers {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			start := time.Now()
+			heights[idx], errs[idx] = headFn(idx)
+			latencies[idx] = time.Since(start)
+		}(idx)
+	}
+	wg.Wait()
+
+	var maxHeight int64
+	for idx, height := range heights {
+		if errs[idx] == nil && height > maxHeight {
+			maxHeight = height
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for idx, prov := range providers {
+		prov.record(latencies[idx], errs[idx])
+		if errs[idx] != nil {
+			if prov.healthy {
+				util.Logger.Errorf("provider removed from rotation, pool=%s, provider=%s, err=%s",
+					p.Name, prov.url, errs[idx].Error())
+			}
+			prov.healthy = false
+			continue
+		}
+
+		prov.height = heights[idx]
+		if maxHeight-prov.height > p.maxHeightLag {
+			if prov.healthy {
+				util.Logger.Errorf("provider removed from rotation, pool=%s, provider=%s, height=%d, max_height=%d",
+					p.Name, prov.url, prov.height, maxHeight)
+			}
+			prov.healthy = false
+			continue
+		}
+
+		if !prov.healthy {
+			// reset the results so that the failures before are not counted again
+			prov.results = []bool{false}
+			prov.healthy = true
+			util.Logger.Infof("provider back to rotation, pool=%s, provider=%s, height=%d", p.Name, prov.url, prov.height)
+		} else if len(prov.results) >= common.ProviderMinCalls && prov.errorRate() > common.ProviderMaxErrorRate {
+			prov.healthy = false
+		}
+	}
+}
+
+// States returns the states of all the providers
+func (p *Pool) States() []*ProviderState {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	var maxHeight int64
+	for _, prov := range p.providers {
+		if prov.height > maxHeight {
+			maxHeight = prov.height
+		}
+	}
+
+	states := make([]*ProviderState, 0, len(p.providers))
+	for _, prov := range p.providers {
+		states = append(states, &ProviderState{
+			Url:        prov.url,
+			Healthy:    prov.healthy,
+			Height:     prov.height,
+			HeightLag:  maxHeight - prov.height,
+			LatencyMs:  float64(prov.latency) / float64(time.Millisecond),
+			Calls:      prov.calls,
+			Errors:     prov.errors,
+			ErrorRate:  prov.errorRate(),
+			LastError:  prov.lastErr,
+			UpdateTime: prov.updateTime,
+		})
+	}
+	return states
+}
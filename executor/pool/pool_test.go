@@ -0,0 +1,138 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+)
+
+func TestPool_Call_retry(t *testing.T) {
+	p := NewPool("test", []string{"p0", "p1"}, 10)
+
+	called := make([]int, 0)
+	err := p.Call(func(idx int) error {
+		called = append(called, idx)
+		if len(called) == 1 {
+			return errors.New("provider down")
+		}
+		return nil
+	})
+	require.Nil(t, err)
+	require.Len(t, called, 2)
+	require.NotEqual(t, called[0], called[1])
+
+	states := p.States()
+	require.Equal(t, int64(1), states[called[0]].Errors)
+	require.Equal(t, int64(0), states[called[1]].Errors)
+}
+
+func TestPool_Call_permanent(t *testing.T) {
+	p := NewPool("test", []string{"p0", "p1"}, 10)
+
+	calls := 0
+	err := p.Call(func(idx int) error {
+		calls++
+		return Permanent(errors.New("claim rejected"))
+	})
+	require.NotNil(t, err)
+	require.Equal(t, "claim rejected", err.Error())
+	require.Equal(t, 1, calls)
+
+	for _, state := range p.States() {
+		require.Equal(t, int64(0), state.Errors)
+	}
+}
+
+func TestPool_Call_errorRate(t *testing.T) {
+	p := NewPool("test", []string{"p0", "p1"}, 10)
+
+	// providers are picked randomly, call enough times so that p0 is picked first at least ProviderMinCalls times
+	for i := 0; i < common.ProviderMinCalls*10; i++ {
+		err := p.Call(func(idx int) error {
+			if idx == 0 {
+				return errors.New("provider down")
+			}
+			return nil
+		})
+		require.Nil(t, err)
+	}
+
+	states := p.States()
+	require.False(t, states[0].Healthy)
+	require.True(t, states[1].Healthy)
+
+	// unhealthy providers are not called any more
+	for i := 0; i < 10; i++ {
+		err := p.Call(func(idx int) error {
+			require.Equal(t, 1, idx)
+			return nil
+		})
+		require.Nil(t, err)
+	}
+}
+
+func TestPool_Check(t *testing.T) {
+	p := NewPool("test", []string{"p0", "p1", "p2"}, 10)
+
+	p.Check(func(idx int) (int64, error) {
+		switch idx {
+		case 0:
+			return 100, nil
+		case 1:
+			return 80, nil
+		default:
+			return 0, errors.New("provider down")
+		}
+	})
+
+	states := p.States()
+	require.True(t, states[0].Healthy)
+	require.False(t, states[1].Healthy)
+	require.Equal(t, int64(20), states[1].HeightLag)
+	require.False(t, states[2].Healthy)
+	require.Equal(t, "provider down", states[2].LastError)
+
+	// lagging providers are back once they catch up
+	p.Check(func(idx int) (int64, error) {
+		return 101, nil
+	})
+	for _, state := range p.States() {
+		require.True(t, state.Healthy)
+	}
+}
+
+func TestPool_Call_allUnhealthy(t *testing.T) {
+	p := NewPool("test", []string{"p0"}, 10)
+	p.Check(func(idx int) (int64, error) {
+		return 0, errors.New("provider down")
+	})
+	require.False(t, p.States()[0].Healthy)
+
+	calls := 0
+	err := p.Call(func(idx int) error {
+		calls++
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestPool_SetProviders(t *testing.T) {
+	p := NewPool("test", []string{"p0", "p1"}, 10)
+
+	err := p.Call(func(idx int) error {
+		return nil
+	})
+	require.Nil(t, err)
+	calls := p.States()[0].Calls + p.States()[1].Calls
+
+	p.SetProviders([]string{"p2", "p1", "p0"})
+	states := p.States()
+	require.Len(t, states, 3)
+	require.Equal(t, "p2", states[0].Url)
+	require.Equal(t, int64(0), states[0].Calls)
+	require.Equal(t, calls, states[1].Calls+states[2].Calls)
+}
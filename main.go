@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/aximchain/go-sdk/common/types"
 	"github.com/jinzhu/gorm"
@@ -12,11 +15,15 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/Sotatek-huytran2/oracle-relayer/admin"
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
 	"github.com/Sotatek-huytran2/oracle-relayer/executor/afc"
 	"github.com/Sotatek-huytran2/oracle-relayer/executor/asc"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/pool"
 	"github.com/Sotatek-huytran2/oracle-relayer/model"
 	"github.com/Sotatek-huytran2/oracle-relayer/observer"
+	"github.com/Sotatek-huytran2/oracle-relayer/recovery"
 	"github.com/Sotatek-huytran2/oracle-relayer/relayer"
+	"github.com/Sotatek-huytran2/oracle-relayer/reload"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
 
@@ -25,7 +32,22 @@ const (
 	flagConfigAwsRegion    = "aws-region"
 	flagConfigAwsSecretKey = "aws-secret-key"
 	flagConfigPath         = "config-path"
+	flagConfigFormat       = "config-format"
 	flagAFCNetwork         = "afc-network"
+	flagConfigSet          = "set"
+
+	flagRecoverChainId    = "chain-id"
+	flagRecoverTxHash     = "tx-hash"
+	flagRecoverFromHeight = "from-height"
+	flagRecoverToHeight   = "to-height"
+	flagRecoverDryRun     = "dry-run"
+)
+
+const (
+	cmdRecover = "recover"
+	cmdConfig  = "config"
+
+	subCmdValidate = "validate"
 )
 
 const (
@@ -36,10 +58,19 @@ const (
 func initFlags() {
 	flag.String(flagConfigPath, "", "config path")
 	flag.String(flagConfigType, "", "config type, local or aws")
+	flag.String(flagConfigFormat, "", "config format, json, yaml or toml, decided by the extension of config path if omitted")
 	flag.String(flagConfigAwsRegion, "", "aws s3 region")
 	flag.String(flagConfigAwsSecretKey, "", "aws s3 secret key")
 	flag.Int(flagAFCNetwork, int(types.TestNetwork), "afc chain network type")
 
+	flag.Uint(flagRecoverChainId, 0, "asc chain id to recover packages of, can be omitted if there is only one chain")
+	flag.String(flagRecoverTxHash, "", "asc tx hash to recover packages from")
+	flag.Int64(flagRecoverFromHeight, 0, "asc height to start recovering packages from")
+	flag.Int64(flagRecoverToHeight, 0, "asc height to stop recovering packages at, inclusive")
+	flag.Bool(flagRecoverDryRun, false, "print the packages to recover without saving them")
+
+	pflag.StringArray(flagConfigSet, nil, "override a config field, eg(--set chain_config.relay_interval=1000), can be repeated")
+
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
 	err := viper.BindPFlags(pflag.CommandLine)
@@ -50,9 +81,26 @@ func initFlags() {
 
 func printUsage() {
 	fmt.Print("usage: ./relayer --afc-network [0 for testnet, 1 for mainnet] --config-type [local or aws] --config-path config_file_path\n")
+	fmt.Print("       ./relayer recover --afc-network [0 for testnet, 1 for mainnet] --config-type [local or aws] --config-path config_file_path " +
+		"[--chain-id asc_chain_id] [--tx-hash asc_tx_hash | --from-height height --to-height height] [--dry-run]\n")
+	fmt.Print("       config fields can be overridden by --set path=value and RELAYER_<PATH> env vars, see docs/config.md\n")
+	fmt.Print("       ./relayer config validate [--config-format json, yaml or toml] config_file_path\n")
 }
 
 func main() {
+	cmd := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		cmd = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	if cmd == cmdConfig {
+		os.Exit(runConfig(os.Args[1:]))
+	}
+	if cmd != "" && cmd != cmdRecover {
+		printUsage()
+		return
+	}
+
 	initFlags()
 
 	afcNetwork := viper.GetInt(flagAFCNetwork)
@@ -77,39 +125,28 @@ func main() {
 		return
 	}
 
-	var config *util.Config
 	if configType == ConfigTypeAws {
-		awsSecretKey := viper.GetString(flagConfigAwsSecretKey)
-		if awsSecretKey == "" {
-			printUsage()
-			return
-		}
-
-		awsRegion := viper.GetString(flagConfigAwsRegion)
-		if awsRegion == "" {
+		if viper.GetString(flagConfigAwsSecretKey) == "" || viper.GetString(flagConfigAwsRegion) == "" {
 			printUsage()
 			return
 		}
+	} else if viper.GetString(flagConfigPath) == "" {
+		printUsage()
+		return
+	}
 
-		configContent, err := util.GetSecret(awsSecretKey, awsRegion)
-		if err != nil {
-			fmt.Printf("get aws config error, err=%s", err.Error())
-			return
-		}
-		config = util.ParseConfigFromJson(configContent)
-	} else {
-		configFilePath := viper.GetString(flagConfigPath)
-		if configFilePath == "" {
-			printUsage()
-			return
-		}
-		config = util.ParseConfigFromFile(configFilePath)
+	config, err := loadConfig(configType)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		os.Exit(1)
 	}
-	config.Validate()
 
 	// init logger
 	util.InitLogger(*config.LogConfig)
-	util.InitAlert(config.AlertConfig)
+	if err := alert.Init(config.AlertConfig); err != nil {
+		fmt.Printf("init alerters error, err=%s\n", err.Error())
+		os.Exit(1)
+	}
 
 	db, err := gorm.Open(config.DBConfig.Dialect, config.DBConfig.DBPath)
 	if err != nil {
@@ -118,20 +155,162 @@ func main() {
 	defer db.Close()
 	model.InitTables(db)
 
-	ascExecutor := asc.NewExecutor(config.ChainConfig.ASCProviders, config)
-	ob := observer.NewObserver(db, config, ascExecutor)
-	go ob.Start()
-
 	afcExecutor, err := afc.NewExecutor(config.ChainConfig.AFCRpcAddrs, types.Network, config)
 	if err != nil {
 		fmt.Printf("new afc executor error, err=%s\n", err.Error())
-		return
+		os.Exit(1)
+	}
+
+	ascExecutors := make([]*asc.Executor, 0, len(config.ChainConfigs))
+	observers := make([]*observer.Observer, 0, len(config.ChainConfigs))
+	recoveries := make([]*recovery.Recovery, 0, len(config.ChainConfigs))
+	for _, chainConfig := range config.ChainConfigs {
+		ascExecutor := asc.NewExecutor(chainConfig, config)
+		ascExecutors = append(ascExecutors, ascExecutor)
+		observers = append(observers, observer.NewObserver(db, config, chainConfig, ascExecutor))
+		recoveries = append(recoveries, recovery.NewRecovery(db, config, chainConfig, ascExecutor, afcExecutor))
+	}
+
+	if cmd == cmdRecover {
+		code := runRecover(recoveries)
+		// os.Exit skips the deferred close
+		db.Close()
+		os.Exit(code)
 	}
+
+	pools := []*pool.Pool{afcExecutor.Pool}
+	go afcExecutor.StartHealthCheck()
+	for _, ascExecutor := range ascExecutors {
+		pools = append(pools, ascExecutor.Pool)
+		go ascExecutor.StartHealthCheck()
+	}
+
+	for _, ob := range observers {
+		go ob.Start()
+	}
+
 	oracleRelayer := relayer.NewRelayer(db, afcExecutor, config)
+	for _, rec := range recoveries {
+		oracleRelayer.Backfillers[rec.ChainConfig.ASCChainId] = rec
+	}
 	go oracleRelayer.Main()
 
-	adm := admin.NewAdmin(config, afcExecutor)
+	reloader := reload.NewReloader(config, func() (*util.Config, error) {
+		return loadConfig(configType)
+	}, afcExecutor, ascExecutors)
+	go reloader.HandleSignals()
+
+	adm := admin.NewAdmin(config, db, observers, oracleRelayer, afcExecutor, recoveries, pools, reloader)
 	go adm.Serve()
 
 	select {}
 }
+
+// loadConfig loads the config of the given type, applies the overrides of env vars and flags, and validates it
+func loadConfig(configType string) (*util.Config, error) {
+	var config *util.Config
+	if configType == ConfigTypeAws {
+		configContent, err := util.GetSecret(viper.GetString(flagConfigAwsSecretKey), viper.GetString(flagConfigAwsRegion))
+		if err != nil {
+			return nil, fmt.Errorf("get aws config error, err=%s", err.Error())
+		}
+		configFormat := viper.GetString(flagConfigFormat)
+		if configFormat == "" {
+			configFormat = util.ConfigFormatJson
+		}
+		config, err = util.ParseConfig([]byte(configContent), configFormat)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		config, err = util.ParseConfigFromFile(viper.GetString(flagConfigPath), viper.GetString(flagConfigFormat))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// config fields are overridden by flags first, then env vars, then the config file
+	if err := config.ApplyEnvOverrides(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	settings, err := pflag.CommandLine.GetStringArray(flagConfigSet)
+	if err != nil {
+		return nil, fmt.Errorf("get %s flag error, err=%s", flagConfigSet, err.Error())
+	}
+	if err := config.ApplyOverrides(settings); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// runRecover recovers the packages of the tx or block range given by flags and returns the exit code
+func runRecover(recoveries []*recovery.Recovery) int {
+	req := &recovery.Request{
+		ChainId:    uint16(viper.GetUint(flagRecoverChainId)),
+		TxHash:     viper.GetString(flagRecoverTxHash),
+		FromHeight: viper.GetInt64(flagRecoverFromHeight),
+		ToHeight:   viper.GetInt64(flagRecoverToHeight),
+		DryRun:     viper.GetBool(flagRecoverDryRun),
+	}
+	if err := req.Validate(); err != nil {
+		fmt.Printf("invalid recover request, err=%s\n", err.Error())
+		printUsage()
+		return 2
+	}
+
+	rec := recovery.FindRecovery(recoveries, req.ChainId)
+	if rec == nil {
+		fmt.Printf("--chain-id should be one of the source chains\n")
+		return 2
+	}
+
+	result, err := rec.Recover(req)
+	if err != nil {
+		fmt.Printf("recover packages error, err=%s\n", err.Error())
+		return 1
+	}
+
+	resultBytes, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		fmt.Printf("marshal result error, err=%s\n", err.Error())
+		return 1
+	}
+	fmt.Println(string(resultBytes))
+	return 0
+}
+
+// runConfig runs the config subcommands and returns the exit code, only validate is supported for now
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != subCmdValidate {
+		printUsage()
+		return 2
+	}
+
+	flagSet := flag.NewFlagSet(subCmdValidate, flag.ContinueOnError)
+	configFormat := flagSet.String(flagConfigFormat, "", "config format, json, yaml or toml")
+	if err := flagSet.Parse(args[1:]); err != nil || flagSet.NArg() != 1 {
+		printUsage()
+		return 2
+	}
+	configPath := flagSet.Arg(0)
+
+	config, err := util.ParseConfigFromFile(configPath, *configFormat)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		return 1
+	}
+	if err := config.ApplyEnvOverrides(os.LookupEnv); err != nil {
+		fmt.Printf("%s\n", err.Error())
+		return 1
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Printf("%s\n", err.Error())
+		return 1
+	}
+	fmt.Printf("config %s is valid\n", configPath)
+	return 0
+}
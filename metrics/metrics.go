@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "oracle_relayer"
+
+	ClaimResultSuccess = "success"
+	ClaimResultFailure = "failure"
+)
+
+var (
+	// AscBlockHeight is the height of the last ASC block fetched by the observer
+	AscBlockHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "asc_block_height",
+		Help:      "Height of the last ASC block fetched by the observer.",
+	}, []string{"chain_id"})
+
+	// AscChainTipLag is the number of blocks the observer is behind the ASC chain tip
+	AscChainTipLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "asc_chain_tip_lag",
+		Help:      "Number of blocks between the ASC chain tip and the last fetched block.",
+	}, []string{"chain_id"})
+
+	// AscReorgCount is the number of reorgs of ASC handled by the observer
+	AscReorgCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "asc_reorg_total",
+		Help:      "Number of ASC reorgs handled by the observer.",
+	}, []string{"chain_id"})
+
+	// PackageCount is the number of cross-chain packages in database by status
+	PackageCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "package_count",
+		Help:      "Number of cross-chain packages in database by status.",
+	}, []string{"chain_id", "status"})
+
+	// AfcOracleSequence is the current oracle sequence of Axim Chain
+	AfcOracleSequence = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "afc_oracle_sequence",
+		Help:      "Current oracle sequence of Axim Chain.",
+	}, []string{"chain_id"})
+
+	// ClaimCount is the number of claims sent to Axim Chain by result
+	ClaimCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "claim_total",
+		Help:      "Number of claims sent to Axim Chain by result.",
+	}, []string{"chain_id", "result"})
+
+	// ClaimLatency is the time taken by claims sent to Axim Chain by result
+	ClaimLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "claim_latency_seconds",
+		Help:      "Time taken by claims sent to Axim Chain by result.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+	}, []string{"chain_id", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		AscBlockHeight,
+		AscChainTipLag,
+		AscReorgCount,
+		PackageCount,
+		AfcOracleSequence,
+		ClaimCount,
+		ClaimLatency,
+	)
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jinzhu/gorm"
@@ -33,23 +35,58 @@ const (
 	PackageStatusClaimed   PackageStatus = 2
 )
 
+// PackageStatuses lists all the package statuses
+var PackageStatuses = []PackageStatus{PackageStatusInit, PackageStatusConfirmed, PackageStatusClaimed}
+
+func (s PackageStatus) String() string {
+	switch s {
+	case PackageStatusInit:
+		return "init"
+	case PackageStatusConfirmed:
+		return "confirmed"
+	case PackageStatusClaimed:
+		return "claimed"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePackageStatus parses the package status from its name or number
+func ParsePackageStatus(str string) (PackageStatus, error) {
+	for _, status := range PackageStatuses {
+		if str == status.String() {
+			return status, nil
+		}
+	}
+
+	num, err := strconv.Atoi(str)
+	if err == nil {
+		for _, status := range PackageStatuses {
+			if PackageStatus(num) == status {
+				return status, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown package status %s", str)
+}
+
 type CrossChainPackageLog struct {
-	Id              int64
-	ChainId         uint16
-	OracleSequence  uint64
-	PackageSequence uint64
-	ChannelId       uint8
-	PayLoad         string `gorm:"type:text"`
-	TxIndex         uint
-
-	Status       PackageStatus
-	BlockHash    string
-	TxHash       string
-	ClaimTxHash  string
-	Height       int64
-	ConfirmedNum int64
-	CreateTime   int64
-	UpdateTime   int64
+	Id              int64  `json:"id"`
+	ChainId         uint16 `json:"chain_id"`
+	OracleSequence  uint64 `json:"oracle_sequence"`
+	PackageSequence uint64 `json:"package_sequence"`
+	ChannelId       uint8  `json:"channel_id"`
+	PayLoad         string `gorm:"type:text" json:"payload"`
+	TxIndex         uint   `json:"tx_index"`
+
+	Status       PackageStatus `json:"status"`
+	BlockHash    string        `json:"block_hash"`
+	TxHash       string        `json:"tx_hash"`
+	ClaimTxHash  string        `json:"claim_tx_hash"`
+	Height       int64         `json:"height"`
+	ConfirmedNum int64         `json:"confirmed_num"`
+	CreateTime   int64         `json:"create_time"`
+	UpdateTime   int64         `json:"update_time"`
 }
 
 func (l *CrossChainPackageLog) BeforeCreate() (err error) {
@@ -62,17 +99,109 @@ func (CrossChainPackageLog) TableName() string {
 	return "cross_chain_package_log"
 }
 
+type ClaimTxStatus int
+
+const (
+	ClaimTxStatusPending   ClaimTxStatus = 0
+	ClaimTxStatusConfirmed ClaimTxStatus = 1
+	ClaimTxStatusFailed    ClaimTxStatus = 2
+)
+
+func (s ClaimTxStatus) String() string {
+	switch s {
+	case ClaimTxStatusPending:
+		return "pending"
+	case ClaimTxStatusConfirmed:
+		return "confirmed"
+	case ClaimTxStatusFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// ClaimTxLog is the claim tx broadcast in sync mode, it is tracked until it is included in a block, rejected or
+// dropped
+type ClaimTxLog struct {
+	Id             int64         `json:"id"`
+	ChainId        uint16        `json:"chain_id"`
+	OracleSequence uint64        `json:"oracle_sequence"`
+	TxHash         string        `json:"tx_hash"`
+	Status         ClaimTxStatus `json:"status"`
+	Height         int64         `json:"height"`
+	ErrorMsg       string        `gorm:"type:text" json:"error_msg"`
+	CreateTime     int64         `json:"create_time"`
+	UpdateTime     int64         `json:"update_time"`
+}
+
+func (ClaimTxLog) TableName() string {
+	return "claim_tx_log"
+}
+
+func (l *ClaimTxLog) BeforeCreate() (err error) {
+	l.CreateTime = time.Now().Unix()
+	l.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+type ReorgLog struct {
+	Id                        int64  `json:"id"`
+	Chain                     string `json:"chain"`
+	CommonAncestorHeight      int64  `json:"common_ancestor_height"`
+	CommonAncestorHash        string `json:"common_ancestor_hash"`
+	OrphanedTipHeight         int64  `json:"orphaned_tip_height"`
+	OrphanedTipHash           string `json:"orphaned_tip_hash"`
+	Depth                     int64  `json:"depth"`
+	DeletedPackageNum         int64  `json:"deleted_package_num"`
+	OrphanedClaimedPackageNum int64  `json:"orphaned_claimed_package_num"`
+	CreateTime                int64  `json:"create_time"`
+}
+
+func (ReorgLog) TableName() string {
+	return "reorg_log"
+}
+
+func (l *ReorgLog) BeforeCreate() (err error) {
+	l.CreateTime = time.Now().Unix()
+	return nil
+}
+
 func InitTables(db *gorm.DB) {
 	if !db.HasTable(&BlockLog{}) {
 		db.CreateTable(&BlockLog{})
-		db.Model(&BlockLog{}).AddUniqueIndex("idx_block_log_height", "height")
+		db.Model(&BlockLog{}).AddUniqueIndex("idx_block_log_chain_height", "chain", "height")
 		db.Model(&BlockLog{}).AddIndex("idx_block_log_create_time", "create_time")
 	}
 
+	// block logs of different chains may have the same height
+	if db.Dialect().HasIndex(BlockLog{}.TableName(), "idx_block_log_height") {
+		db.Model(&BlockLog{}).RemoveIndex("idx_block_log_height")
+		db.Model(&BlockLog{}).AddUniqueIndex("idx_block_log_chain_height", "chain", "height")
+	}
+
 	if !db.HasTable(&CrossChainPackageLog{}) {
 		db.CreateTable(&CrossChainPackageLog{})
 		db.Model(&CrossChainPackageLog{}).AddIndex("idx_package_log_channel_seq", "channel_id", "oracle_sequence")
 		db.Model(&CrossChainPackageLog{}).AddIndex("idx_package_log_height", "height")
 		db.Model(&CrossChainPackageLog{}).AddIndex("idx_package_log_status", "status")
 	}
+
+	// indexes to find the gaps of oracle sequences and package sequences
+	if !db.Dialect().HasIndex(CrossChainPackageLog{}.TableName(), "idx_package_log_chain_oracle_seq") {
+		db.Model(&CrossChainPackageLog{}).AddIndex("idx_package_log_chain_oracle_seq", "chain_id", "oracle_sequence")
+	}
+	if !db.Dialect().HasIndex(CrossChainPackageLog{}.TableName(), "idx_package_log_chain_channel_package_seq") {
+		db.Model(&CrossChainPackageLog{}).AddIndex("idx_package_log_chain_channel_package_seq", "chain_id", "channel_id", "package_sequence")
+	}
+
+	if !db.HasTable(&ClaimTxLog{}) {
+		db.CreateTable(&ClaimTxLog{})
+		db.Model(&ClaimTxLog{}).AddUniqueIndex("idx_claim_tx_log_tx_hash", "tx_hash")
+		db.Model(&ClaimTxLog{}).AddIndex("idx_claim_tx_log_status", "status")
+	}
+
+	if !db.HasTable(&ReorgLog{}) {
+		db.CreateTable(&ReorgLog{})
+		db.Model(&ReorgLog{}).AddIndex("idx_reorg_log_create_time", "create_time")
+	}
 }
@@ -1,13 +1,18 @@
 package observer
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/jinzhu/gorm"
 
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
 	"github.com/Sotatek-huytran2/oracle-relayer/common"
 	"github.com/Sotatek-huytran2/oracle-relayer/executor"
+	"github.com/Sotatek-huytran2/oracle-relayer/metrics"
 	"github.com/Sotatek-huytran2/oracle-relayer/model"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
@@ -15,23 +20,60 @@ import (
 type Observer struct {
 	DB          *gorm.DB
 	Config      *util.Config
+	ChainConfig *util.ChainConfig
 	AscExecutor executor.AscExecutor
+
+	newBlockCh chan int64
+	subscribed int32
 }
 
-// NewObserver returns the observer instance
-func NewObserver(db *gorm.DB, cfg *util.Config, ascExecutor executor.AscExecutor) *Observer {
+// NewObserver returns the observer instance of the given source chain
+func NewObserver(db *gorm.DB, cfg *util.Config, chainCfg *util.ChainConfig, ascExecutor executor.AscExecutor) *Observer {
 	return &Observer{
 		DB:          db,
 		Config:      cfg,
+		ChainConfig: chainCfg,
 		AscExecutor: ascExecutor,
+
+		newBlockCh: make(chan int64, 1),
 	}
 }
 
 // Start starts the routines of observer
 func (ob *Observer) Start() {
-	go ob.Fetch(ob.Config.ChainConfig.ASCStartHeight)
+	go ob.Fetch(ob.ChainConfig.ASCStartHeight)
 	go ob.Prune()
 	go ob.Alert()
+	go ob.CollectMetrics()
+
+	if len(ob.ChainConfig.ASCWsProviders) > 0 {
+		go ob.Subscribe()
+	}
+}
+
+// Subscribe subscribes to new blocks of ASC to drive Fetch, Fetch falls back to polling if the subscription drops
+func (ob *Observer) Subscribe() {
+	for {
+		atomic.StoreInt32(&ob.subscribed, 1)
+		err := ob.AscExecutor.SubscribeBlocks(context.Background(), ob.newBlockCh)
+		atomic.StoreInt32(&ob.subscribed, 0)
+
+		util.Logger.Errorf("subscribe blocks error, fall back to polling, err=%s", err.Error())
+		time.Sleep(common.ObserverResubscribeInterval)
+	}
+}
+
+// waitForNextBlock waits for the next block event if blocks are subscribed, otherwise it sleeps for the fetch interval
+func (ob *Observer) waitForNextBlock() {
+	if atomic.LoadInt32(&ob.subscribed) == 0 {
+		time.Sleep(common.ObserverFetchInterval)
+		return
+	}
+
+	select {
+	case <-ob.newBlockCh:
+	case <-time.After(common.ObserverSubscribeFetchInterval):
+	}
 }
 
 // Fetch starts the main routine for fetching blocks of ASC
@@ -49,17 +91,26 @@ func (ob *Observer) Fetch(startHeight int64) {
 			nextHeight = startHeight
 		}
 
-		util.Logger.Infof("fetch block, height=%d", nextHeight)
-		err = ob.fetchBlock(curBlockLog.Height, nextHeight, curBlockLog.BlockHash)
+		if ob.ChainConfig.ASCFetchWindow > 1 {
+			err = ob.fetchBlocks(curBlockLog.Height, nextHeight, curBlockLog.BlockHash)
+		} else {
+			util.Logger.Infof("fetch block, height=%d", nextHeight)
+			err = ob.fetchBlock(curBlockLog.Height, nextHeight, curBlockLog.BlockHash)
+		}
 		if err != nil {
 			util.Logger.Errorf("fetch block error, err=%s", err.Error())
-			time.Sleep(common.ObserverFetchInterval)
+			ob.waitForNextBlock()
+			continue
 		}
+
+		msg := fmt.Sprintf("[%s] resolved: blocks of smart chain are fetched again, chain_id=%d",
+			ob.Config.Alert().Moniker, ob.ChainConfig.ASCChainId)
+		alert.Resolve(alert.ChainDedupKey(alert.IncidentDedupKeyReorg, ob.ChainConfig.ASCChainId), msg)
 	}
 }
 
 // fetchBlock fetches the next block of ASC and saves it to database. if the next block hash
-// does not match to the parent hash, the current block will be deleted for there is a fork.
+// does not match to the parent hash, the blocks after the common ancestor will be rolled back for there is a fork.
 func (ob *Observer) fetchBlock(curHeight, nextHeight int64, curBlockHash string) error {
 	blockAndPackageLogs, err := ob.AscExecutor.GetBlockAndPackages(nextHeight)
 	if err != nil {
@@ -68,9 +119,10 @@ func (ob *Observer) fetchBlock(curHeight, nextHeight int64, curBlockHash string)
 
 	parentHash := blockAndPackageLogs.ParentBlockHash
 	if curHeight != 0 && parentHash != curBlockHash {
-		return ob.DeleteBlockAndPackages(curHeight)
+		return ob.HandleReorg(curHeight)
 	} else {
 		nextBlockLog := model.BlockLog{
+			Chain:      ob.ChainConfig.ASCChainName,
 			BlockHash:  blockAndPackageLogs.BlockHash,
 			ParentHash: parentHash,
 			Height:     blockAndPackageLogs.Height,
@@ -90,6 +142,45 @@ func (ob *Observer) fetchBlock(curHeight, nextHeight int64, curBlockHash string)
 	return nil
 }
 
+// fetchBlocks fetches a window of blocks of ASC starting from the next height and saves them to database in one
+// transaction. Blocks which are not confirmed yet are fetched one by one by fetchBlock, so are the blocks whose
+// parent hash does not match to the current block hash.
+func (ob *Observer) fetchBlocks(curHeight, nextHeight int64, curBlockHash string) error {
+	latestHeight, err := ob.AscExecutor.GetLatestHeight()
+	if err != nil {
+		return fmt.Errorf("get latest height error, err=%s", err.Error())
+	}
+
+	toHeight := latestHeight - ob.ChainConfig.ASCConfirmNum
+	if maxHeight := nextHeight + ob.ChainConfig.ASCFetchWindow - 1; toHeight > maxHeight {
+		toHeight = maxHeight
+	}
+	if toHeight <= nextHeight {
+		util.Logger.Infof("fetch block, height=%d", nextHeight)
+		return ob.fetchBlock(curHeight, nextHeight, curBlockHash)
+	}
+
+	util.Logger.Infof("fetch blocks, from=%d, to=%d", nextHeight, toHeight)
+	blocks, err := ob.AscExecutor.GetBlocksAndPackages(nextHeight, toHeight)
+	if err != nil {
+		return fmt.Errorf("get blocks info error, from=%d, to=%d, err=%s", nextHeight, toHeight, err.Error())
+	}
+
+	if curHeight != 0 && blocks[0].ParentBlockHash != curBlockHash {
+		return ob.fetchBlock(curHeight, nextHeight, curBlockHash)
+	}
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].ParentBlockHash != blocks[i-1].BlockHash {
+			return fmt.Errorf("parent hash mismatch in fetched blocks, height=%d", blocks[i].Height)
+		}
+	}
+
+	if err := ob.SaveBlocksAndPackages(blocks); err != nil {
+		return err
+	}
+	return ob.UpdateConfirmedNum(blocks[len(blocks)-1].Height)
+}
+
 // DeleteBlockAndPackages deletes the block and txs of the given height
 func (ob *Observer) DeleteBlockAndPackages(height int64) error {
 	tx := ob.DB.Begin()
@@ -97,12 +188,13 @@ func (ob *Observer) DeleteBlockAndPackages(height int64) error {
 		return err
 	}
 
-	if err := tx.Where("height = ?", height).Delete(model.BlockLog{}).Error; err != nil {
+	if err := tx.Where("chain = ? and height = ?", ob.ChainConfig.ASCChainName, height).Delete(model.BlockLog{}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	if err := tx.Where("height = ? and status = ?", height, model.PackageStatusInit).Delete(model.CrossChainPackageLog{}).Error; err != nil {
+	if err := tx.Where("chain_id = ? and height = ? and status = ?", ob.ChainConfig.ASCChainId, height, model.PackageStatusInit).
+		Delete(model.CrossChainPackageLog{}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -110,9 +202,11 @@ func (ob *Observer) DeleteBlockAndPackages(height int64) error {
 	return tx.Commit().Error
 }
 
-// UpdateConfirmedNum updates confirmation number of cross-chain packages.
+// UpdateConfirmedNum updates confirmation number of cross-chain packages. Packages are confirmed once they have
+// enough confirmations in depth mode, or once their blocks are finalized in finalized and safe modes.
 func (ob *Observer) UpdateConfirmedNum(height int64) error {
-	err := ob.DB.Model(model.CrossChainPackageLog{}).Where("status = ?", model.PackageStatusInit).Updates(
+	err := ob.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ? and status = ?",
+		ob.ChainConfig.ASCChainId, model.PackageStatusInit).Updates(
 		map[string]interface{}{
 			"confirmed_num": gorm.Expr("? - height", height+1),
 			"update_time":   time.Now().Unix(),
@@ -121,8 +215,19 @@ func (ob *Observer) UpdateConfirmedNum(height int64) error {
 		return err
 	}
 
-	err = ob.DB.Model(model.CrossChainPackageLog{}).Where("status = ? and confirmed_num >= ?",
-		model.PackageStatusInit, ob.Config.ChainConfig.ASCConfirmNum).Updates(
+	query := ob.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ?", ob.ChainConfig.ASCChainId)
+	confirmMode := ob.ChainConfig.ASCConfirmMode
+	if confirmMode == util.ConfirmModeFinalized || confirmMode == util.ConfirmModeSafe {
+		finalizedHeight, err := ob.AscExecutor.GetFinalizedHeight(confirmMode)
+		if err != nil {
+			return fmt.Errorf("get %s height error, err=%s", confirmMode, err.Error())
+		}
+		query = query.Where("status = ? and height <= ?", model.PackageStatusInit, finalizedHeight)
+	} else {
+		query = query.Where("status = ? and confirmed_num >= ?", model.PackageStatusInit, ob.ChainConfig.ASCConfirmNum)
+	}
+
+	err = query.Updates(
 		map[string]interface{}{
 			"status":      model.PackageStatusConfirmed,
 			"update_time": time.Now().Unix(),
@@ -144,7 +249,8 @@ func (ob *Observer) Prune() {
 
 			continue
 		}
-		err = ob.DB.Where("height < ?", curBlockLog.Height-common.ObserverMaxBlockNumber).Delete(model.BlockLog{}).Error
+		err = ob.DB.Where("chain = ? and height < ?", ob.ChainConfig.ASCChainName, curBlockLog.Height-common.ObserverMaxBlockNumber).
+			Delete(model.BlockLog{}).Error
 		if err != nil {
 			util.Logger.Infof("prune block logs error, err=%s", err.Error())
 		}
@@ -159,13 +265,29 @@ func (ob *Observer) SaveBlockAndPackages(blockLog *model.BlockLog, packages []in
 		return err
 	}
 
-	if err := tx.Create(blockLog).Error; err != nil {
+	if err := saveBlockAndPackages(tx, blockLog, packages); err != nil {
 		tx.Rollback()
 		return err
 	}
+	return tx.Commit().Error
+}
 
-	for _, pack := range packages {
-		if err := tx.Create(pack).Error; err != nil {
+// SaveBlocksAndPackages saves blocks and their packages to database in one transaction
+func (ob *Observer) SaveBlocksAndPackages(blocks []*common.BlockAndPackageLogs) error {
+	tx := ob.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		blockLog := model.BlockLog{
+			Chain:      ob.ChainConfig.ASCChainName,
+			BlockHash:  block.BlockHash,
+			ParentHash: block.ParentBlockHash,
+			Height:     block.Height,
+			BlockTime:  block.BlockTime,
+		}
+		if err := saveBlockAndPackages(tx, &blockLog, block.Packages); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -173,17 +295,30 @@ func (ob *Observer) SaveBlockAndPackages(blockLog *model.BlockLog, packages []in
 	return tx.Commit().Error
 }
 
+func saveBlockAndPackages(tx *gorm.DB, blockLog *model.BlockLog, packages []interface{}) error {
+	if err := tx.Create(blockLog).Error; err != nil {
+		return err
+	}
+
+	for _, pack := range packages {
+		if err := tx.Create(pack).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetCurrentBlockLog returns the highest block log
 func (ob *Observer) GetCurrentBlockLog() (*model.BlockLog, error) {
 	blockLog := model.BlockLog{}
-	err := ob.DB.Order("height desc").First(&blockLog).Error
+	err := ob.DB.Where("chain = ?", ob.ChainConfig.ASCChainName).Order("height desc").First(&blockLog).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, err
 	}
 	return &blockLog, nil
 }
 
-// Alert sends alerts to tg group if there is no new block fetched in a specific time
+// Alert sends alerts if there is no new block fetched in a specific time, and resolves them once blocks are fetched again
 func (ob *Observer) Alert() {
 	for {
 		curOtherChainBlockLog, err := ob.GetCurrentBlockLog()
@@ -194,14 +329,72 @@ func (ob *Observer) Alert() {
 			continue
 		}
 		if curOtherChainBlockLog.Height > 0 {
-			if time.Now().Unix()-curOtherChainBlockLog.CreateTime > ob.Config.AlertConfig.BlockUpdateTimeOut {
-				msg := fmt.Sprintf("[%s] last smart chain block fetched at %s, height=%d",
-					ob.Config.AlertConfig.Moniker, time.Unix(curOtherChainBlockLog.CreateTime, 0).String(), curOtherChainBlockLog.Height)
-				util.SendTelegramMessage(msg)
-				util.SendPagerDutyAlert(msg, util.IncidentDedupKeyBlockTimeout)
+			dedupKey := alert.ChainDedupKey(alert.IncidentDedupKeyBlockTimeout, ob.ChainConfig.ASCChainId)
+			alertConfig := ob.Config.Alert()
+			delay := time.Now().Unix() - curOtherChainBlockLog.CreateTime
+			if delay > alertConfig.BlockUpdateTimeOut {
+				severity := alertConfig.BlockUpdateSeverity
+				if alertConfig.BlockUpdateCriticalTimeOut > 0 && delay > alertConfig.BlockUpdateCriticalTimeOut {
+					severity = util.SeverityCritical
+				}
+				msg := fmt.Sprintf("[%s] last smart chain block fetched at %s, chain_id=%d, height=%d",
+					alertConfig.Moniker, time.Unix(curOtherChainBlockLog.CreateTime, 0).String(),
+					ob.ChainConfig.ASCChainId, curOtherChainBlockLog.Height)
+				alert.Fire(dedupKey, severity, msg)
+			} else {
+				msg := fmt.Sprintf("[%s] resolved: smart chain blocks are fetched again, chain_id=%d, height=%d",
+					alertConfig.Moniker, ob.ChainConfig.ASCChainId, curOtherChainBlockLog.Height)
+				alert.Resolve(dedupKey, msg)
 			}
 		}
 
 		time.Sleep(common.ObserverAlertInterval)
 	}
 }
+
+// CollectMetrics updates the metrics of fetched blocks and packages periodically
+func (ob *Observer) CollectMetrics() {
+	for {
+		err := ob.updateMetrics()
+		if err != nil {
+			util.Logger.Errorf("update metrics error, err=%s", err.Error())
+		}
+
+		time.Sleep(common.ObserverMetricsInterval)
+	}
+}
+
+func (ob *Observer) updateMetrics() error {
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	if err != nil {
+		return err
+	}
+	chainLabel := strconv.Itoa(int(ob.ChainConfig.ASCChainId))
+	metrics.AscBlockHeight.WithLabelValues(chainLabel).Set(float64(curBlockLog.Height))
+
+	latestHeight, err := ob.AscExecutor.GetLatestHeight()
+	if err != nil {
+		return err
+	}
+	if curBlockLog.Height > 0 && latestHeight >= curBlockLog.Height {
+		metrics.AscChainTipLag.WithLabelValues(chainLabel).Set(float64(latestHeight - curBlockLog.Height))
+	}
+
+	var statusCounts []struct {
+		Status model.PackageStatus
+		Count  int64
+	}
+	err = ob.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ?", ob.ChainConfig.ASCChainId).
+		Select("status, count(*) as count").Group("status").Scan(&statusCounts).Error
+	if err != nil {
+		return err
+	}
+
+	for _, status := range model.PackageStatuses {
+		metrics.PackageCount.WithLabelValues(chainLabel, status.String()).Set(0)
+	}
+	for _, statusCount := range statusCounts {
+		metrics.PackageCount.WithLabelValues(chainLabel, statusCount.Status.String()).Set(float64(statusCount.Count))
+	}
+	return nil
+}
@@ -2,15 +2,20 @@ package observer
 
 import (
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/Sotatek-huytran2/oracle-relayer/common"
 	"github.com/Sotatek-huytran2/oracle-relayer/executor/mock"
+	"github.com/Sotatek-huytran2/oracle-relayer/metrics"
 	"github.com/Sotatek-huytran2/oracle-relayer/model"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
@@ -26,7 +31,7 @@ func TestObserver_fetchBlock_error(t *testing.T) {
 	ascExecutor := mock.NewMockAscExecutor(ctrl)
 	ascExecutor.EXPECT().GetBlockAndPackages(gomock.Any()).AnyTimes().Return(nil, errors.New("error"))
 
-	ob := NewObserver(db, config, ascExecutor)
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
 	err = ob.fetchBlock(1, 2, "1")
 	require.NotNil(t, err, "error should not be nil")
 
@@ -48,8 +53,13 @@ func TestObserver_fetchBlock_error_wrongParentHash(t *testing.T) {
 			BlockHash:       "3",
 			ParentBlockHash: "2_1",
 		}, nil)
+	ascExecutor.EXPECT().GetBlockHeaders(int64(1), int64(2)).AnyTimes().Return(
+		[]*common.BlockHeader{
+			{Height: 1, BlockHash: "1", ParentBlockHash: "0"},
+			{Height: 2, BlockHash: "2_1", ParentBlockHash: "1"},
+		}, nil)
 
-	ob := NewObserver(db, config, ascExecutor)
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
 
 	blockLog1 := &model.BlockLog{
 		Height:     1,
@@ -124,7 +134,7 @@ func TestObserver_fetchBlock_error_rightParentHash(t *testing.T) {
 			},
 		}, nil)
 
-	ob := NewObserver(db, config, ascExecutor)
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
 
 	blockLog1 := &model.BlockLog{
 		Height:     1,
@@ -157,3 +167,259 @@ func TestObserver_fetchBlock_error_rightParentHash(t *testing.T) {
 	require.Nil(t, err, "error should be nil")
 	require.Equal(t, len(newPackages), 2, "length of packages should be 2")
 }
+
+func TestObserver_updateMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetLatestHeight().AnyTimes().Return(int64(10), nil)
+
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
+
+	db.Create(&model.BlockLog{
+		Height:     4,
+		BlockHash:  "4",
+		ParentHash: "3",
+	})
+	db.Create(&model.CrossChainPackageLog{
+		ChainId:         96,
+		OracleSequence:  1,
+		PackageSequence: 1,
+		ChannelId:       2,
+		Height:          4,
+		Status:          model.PackageStatusConfirmed,
+	})
+	db.Create(&model.CrossChainPackageLog{
+		ChainId:         96,
+		OracleSequence:  1,
+		PackageSequence: 2,
+		ChannelId:       2,
+		Height:          4,
+		Status:          model.PackageStatusConfirmed,
+	})
+
+	err = ob.updateMetrics()
+	require.Nil(t, err, "error should be nil")
+
+	require.Equal(t, float64(4), testutil.ToFloat64(metrics.AscBlockHeight.WithLabelValues("96")))
+	require.Equal(t, float64(6), testutil.ToFloat64(metrics.AscChainTipLag.WithLabelValues("96")))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.PackageCount.WithLabelValues("96", model.PackageStatusConfirmed.String())))
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.PackageCount.WithLabelValues("96", model.PackageStatusClaimed.String())))
+}
+
+func TestObserver_fetchBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.ASCFetchWindow = 10
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	blocks := make([]*common.BlockAndPackageLogs, 0)
+	for height := int64(2); height <= 11; height++ {
+		blocks = append(blocks, &common.BlockAndPackageLogs{
+			Height:          height,
+			BlockHash:       fmt.Sprintf("%d", height),
+			ParentBlockHash: fmt.Sprintf("%d", height-1),
+			Packages:        []interface{}{},
+		})
+	}
+	blocks[1].Packages = []interface{}{
+		&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  1,
+			PackageSequence: 1,
+			ChannelId:       2,
+			Height:          3,
+			TxHash:          "tx_hash",
+		},
+	}
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetLatestHeight().AnyTimes().Return(int64(20), nil)
+	ascExecutor.EXPECT().GetBlocksAndPackages(int64(2), int64(11)).Times(1).Return(blocks, nil)
+
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
+
+	db.Create(&model.BlockLog{
+		Height:     1,
+		BlockHash:  "1",
+		ParentHash: "0",
+	})
+
+	err = ob.fetchBlocks(1, 2, "1")
+	require.Nil(t, err, "error should be nil")
+
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, int64(11), curBlockLog.Height)
+
+	packageLog := &model.CrossChainPackageLog{}
+	err = db.Where("height = ?", 3).First(packageLog).Error
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, int64(9), packageLog.ConfirmedNum)
+	require.Equal(t, model.PackageStatusConfirmed, packageLog.Status)
+}
+
+func TestObserver_fetchBlocks_nearTip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.ASCFetchWindow = 10
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetLatestHeight().AnyTimes().Return(int64(3), nil)
+	ascExecutor.EXPECT().GetBlockAndPackages(int64(2)).Times(1).Return(
+		&common.BlockAndPackageLogs{
+			Height:          2,
+			BlockHash:       "2",
+			ParentBlockHash: "1",
+		}, nil)
+
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
+
+	db.Create(&model.BlockLog{
+		Height:     1,
+		BlockHash:  "1",
+		ParentHash: "0",
+	})
+
+	err = ob.fetchBlocks(1, 2, "1")
+	require.Nil(t, err, "error should be nil")
+
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, int64(2), curBlockLog.Height)
+}
+
+func TestObserver_waitForNextBlock_subscribed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
+	atomic.StoreInt32(&ob.subscribed, 1)
+
+	ob.newBlockCh <- 2
+
+	start := time.Now()
+	ob.waitForNextBlock()
+	require.True(t, time.Since(start) < common.ObserverFetchInterval, "should not wait for the fetch interval")
+}
+
+func TestObserver_HandleReorg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	// blocks 3 to 5 are on an orphaned fork
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetBlockHeaders(int64(1), int64(5)).Times(1).Return(
+		[]*common.BlockHeader{
+			{Height: 1, BlockHash: "1", ParentBlockHash: "0"},
+			{Height: 2, BlockHash: "2", ParentBlockHash: "1"},
+			{Height: 3, BlockHash: "3_1", ParentBlockHash: "2"},
+			{Height: 4, BlockHash: "4_1", ParentBlockHash: "3_1"},
+			{Height: 5, BlockHash: "5_1", ParentBlockHash: "4_1"},
+		}, nil)
+
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
+
+	for height := int64(1); height <= 5; height++ {
+		db.Create(&model.BlockLog{
+			Height:     height,
+			BlockHash:  fmt.Sprintf("%d", height),
+			ParentHash: fmt.Sprintf("%d", height-1),
+		})
+	}
+	statuses := []model.PackageStatus{model.PackageStatusClaimed, model.PackageStatusConfirmed, model.PackageStatusInit}
+	for i, status := range statuses {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  uint64(i + 1),
+			PackageSequence: uint64(i + 1),
+			ChannelId:       2,
+			Height:          int64(i + 3),
+			Status:          status,
+			TxHash:          fmt.Sprintf("tx_hash_%d", i),
+		})
+	}
+
+	err = ob.HandleReorg(5)
+	require.Nil(t, err, "error should be nil")
+
+	curBlockLog, err := ob.GetCurrentBlockLog()
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, int64(2), curBlockLog.Height)
+
+	packages := make([]*model.CrossChainPackageLog, 0)
+	err = db.Find(&packages).Error
+	require.Nil(t, err, "error should be nil")
+	require.Len(t, packages, 1)
+	require.Equal(t, model.PackageStatusClaimed, packages[0].Status)
+
+	reorgLog := &model.ReorgLog{}
+	err = db.First(reorgLog).Error
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, int64(2), reorgLog.CommonAncestorHeight)
+	require.Equal(t, int64(3), reorgLog.Depth)
+	require.Equal(t, int64(2), reorgLog.DeletedPackageNum)
+	require.Equal(t, int64(1), reorgLog.OrphanedClaimedPackageNum)
+}
+
+func TestObserver_UpdateConfirmedNum_finalized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.ASCConfirmMode = util.ConfirmModeFinalized
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetFinalizedHeight(util.ConfirmModeFinalized).Times(1).Return(int64(3), nil)
+
+	ob := NewObserver(db, config, config.ChainConfig, ascExecutor)
+
+	for height := int64(3); height <= 4; height++ {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  uint64(height),
+			PackageSequence: uint64(height),
+			ChannelId:       2,
+			Height:          height,
+			TxHash:          "tx_hash",
+		})
+	}
+
+	// both packages have enough confirmations but only the one in the finalized block is confirmed
+	err = ob.UpdateConfirmedNum(10)
+	require.Nil(t, err, "error should be nil")
+
+	finalizedPackage := &model.CrossChainPackageLog{}
+	err = db.Where("height = ?", 3).First(finalizedPackage).Error
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, model.PackageStatusConfirmed, finalizedPackage.Status)
+
+	unfinalizedPackage := &model.CrossChainPackageLog{}
+	err = db.Where("height = ?", 4).First(unfinalizedPackage).Error
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, model.PackageStatusInit, unfinalizedPackage.Status)
+	require.Equal(t, int64(7), unfinalizedPackage.ConfirmedNum)
+}
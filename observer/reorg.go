@@ -0,0 +1,146 @@
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/metrics"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// HandleReorg rolls back the blocks and packages after the common ancestor of the saved blocks and the canonical
+// chain of ASC. The current height is the height of the highest saved block.
+func (ob *Observer) HandleReorg(curHeight int64) error {
+	ancestor, err := ob.findCommonAncestor(curHeight)
+	if err != nil {
+		return err
+	}
+
+	orphanedTip, err := ob.GetCurrentBlockLog()
+	if err != nil {
+		return err
+	}
+
+	reorgLog, claimedPackages, err := ob.rollback(ancestor, orphanedTip)
+	if err != nil {
+		return err
+	}
+	metrics.AscReorgCount.WithLabelValues(strconv.Itoa(int(ob.ChainConfig.ASCChainId))).Inc()
+
+	util.Logger.Infof("reorg handled, common_ancestor=%d, orphaned_tip=%d, depth=%d, deleted_packages=%d",
+		reorgLog.CommonAncestorHeight, reorgLog.OrphanedTipHeight, reorgLog.Depth, reorgLog.DeletedPackageNum)
+
+	if len(claimedPackages) > 0 {
+		descriptions := make([]string, 0, len(claimedPackages))
+		for _, pack := range claimedPackages {
+			descriptions = append(descriptions, fmt.Sprintf("oracle_seq=%d, channel_id=%d, package_seq=%d, tx_hash=%s, claim_tx_hash=%s",
+				pack.OracleSequence, pack.ChannelId, pack.PackageSequence, pack.TxHash, pack.ClaimTxHash))
+		}
+		msg := fmt.Sprintf("[%s] claimed cross chain packages are on an orphaned fork of smart chain, "+
+			"chain_id=%d, common_ancestor=%d, orphaned_tip=%d, packages:\n%s",
+			ob.Config.Alert().Moniker, ob.ChainConfig.ASCChainId, reorgLog.CommonAncestorHeight, reorgLog.OrphanedTipHeight, strings.Join(descriptions, "\n"))
+		util.Logger.Error(msg)
+		// every reorg orphaning claimed packages is an incident of its own, which is resolved by the operators
+		dedupKey := fmt.Sprintf("%s_%d", alert.ChainDedupKey(alert.IncidentDedupKeyReorg, ob.ChainConfig.ASCChainId), reorgLog.Id)
+		alert.Send(dedupKey, util.SeverityCritical, msg)
+	}
+	return nil
+}
+
+// findCommonAncestor returns the highest saved block which is also in the canonical chain of ASC. The saved
+// blocks are compared with the canonical headers from the current height downwards, a window at a time.
+func (ob *Observer) findCommonAncestor(curHeight int64) (*model.BlockLog, error) {
+	lowestBlockLog := model.BlockLog{}
+	err := ob.DB.Where("chain = ?", ob.ChainConfig.ASCChainName).Order("height asc").First(&lowestBlockLog).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for toHeight := curHeight; toHeight >= lowestBlockLog.Height; toHeight -= common.ObserverReorgSearchWindow {
+		fromHeight := toHeight - common.ObserverReorgSearchWindow + 1
+		if fromHeight < lowestBlockLog.Height {
+			fromHeight = lowestBlockLog.Height
+		}
+
+		headers, err := ob.AscExecutor.GetBlockHeaders(fromHeight, toHeight)
+		if err != nil {
+			return nil, fmt.Errorf("get block headers error, from=%d, to=%d, err=%s", fromHeight, toHeight, err.Error())
+		}
+		canonicalHashes := make(map[int64]string, len(headers))
+		for _, header := range headers {
+			canonicalHashes[header.Height] = header.BlockHash
+		}
+
+		blockLogs := make([]*model.BlockLog, 0)
+		err = ob.DB.Where("chain = ? and height >= ? and height <= ?", ob.ChainConfig.ASCChainName, fromHeight, toHeight).Order("height desc").Find(&blockLogs).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, blockLog := range blockLogs {
+			if canonicalHashes[blockLog.Height] == blockLog.BlockHash {
+				return blockLog, nil
+			}
+		}
+	}
+
+	msg := fmt.Sprintf("[%s] no common ancestor of smart chain found in saved blocks, chain_id=%d, lowest_height=%d, current_height=%d",
+		ob.Config.Alert().Moniker, ob.ChainConfig.ASCChainId, lowestBlockLog.Height, curHeight)
+	// it is resolved once a block is fetched without error
+	alert.Fire(alert.ChainDedupKey(alert.IncidentDedupKeyReorg, ob.ChainConfig.ASCChainId), util.SeverityCritical, msg)
+	return nil, errors.New(msg)
+}
+
+// rollback deletes the blocks and unclaimed packages after the common ancestor and records the reorg, the claimed
+// packages after the common ancestor are kept and returned
+func (ob *Observer) rollback(ancestor, orphanedTip *model.BlockLog) (*model.ReorgLog, []*model.CrossChainPackageLog, error) {
+	chainId := ob.ChainConfig.ASCChainId
+
+	tx := ob.DB.Begin()
+	if err := tx.Error; err != nil {
+		return nil, nil, err
+	}
+
+	claimedPackages := make([]*model.CrossChainPackageLog, 0)
+	if err := tx.Where("chain_id = ? and height > ? and status = ?", chainId, ancestor.Height, model.PackageStatusClaimed).
+		Find(&claimedPackages).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	if err := tx.Where("chain = ? and height > ?", ob.ChainConfig.ASCChainName, ancestor.Height).Delete(model.BlockLog{}).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	deleteResult := tx.Where("chain_id = ? and height > ? and status in (?)", chainId, ancestor.Height,
+		[]model.PackageStatus{model.PackageStatusInit, model.PackageStatusConfirmed}).Delete(model.CrossChainPackageLog{})
+	if err := deleteResult.Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	reorgLog := &model.ReorgLog{
+		Chain:                     ob.ChainConfig.ASCChainName,
+		CommonAncestorHeight:      ancestor.Height,
+		CommonAncestorHash:        ancestor.BlockHash,
+		OrphanedTipHeight:         orphanedTip.Height,
+		OrphanedTipHash:           orphanedTip.BlockHash,
+		Depth:                     orphanedTip.Height - ancestor.Height,
+		DeletedPackageNum:         deleteResult.RowsAffected,
+		OrphanedClaimedPackageNum: int64(len(claimedPackages)),
+	}
+	if err := tx.Create(reorgLog).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, err
+	}
+	return reorgLog, claimedPackages, nil
+}
@@ -0,0 +1,155 @@
+package recovery
+
+import (
+	"fmt"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/relayer"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+var _ relayer.Backfiller = (*Recovery)(nil)
+
+// Backfill searches ASC for the packages of the given gaps by the indexed topics of the cross-chain package event.
+// The packages of the oracle sequences which are not claimed yet are saved with their own oracle sequence. The
+// packages of the claimed oracle sequences which Axim Chain has accepted already are saved as claimed, and the others
+// are attached to the next pending oracle sequence by Inject. The oracle sequence gaps below the current one are
+// skipped, since Axim Chain has accepted them already.
+func (r *Recovery) Backfill(gaps []relayer.SequenceGap) error {
+	chainId := r.ChainConfig.ASCChainId
+	sequence, err := r.AfcExecutor.GetCurrentSequence(chainId)
+	if err != nil {
+		return fmt.Errorf("get current sequence error, err=%s", err.Error())
+	}
+
+	packages := make([]*model.CrossChainPackageLog, 0)
+	for _, gap := range gaps {
+		if gap.Column == relayer.GapColumnOracleSequence {
+			if int64(gap.To) < sequence {
+				continue
+			}
+			if int64(gap.From) < sequence {
+				gap.From = uint64(sequence)
+			}
+		}
+
+		gapPackages, err := r.searchGap(gap)
+		if err != nil {
+			util.Logger.Errorf("search packages of gap error, chain_id=%d, gap=%s, err=%s", chainId, gap.String(), err.Error())
+			continue
+		}
+		packages = append(packages, gapPackages...)
+	}
+
+	pending := make([]*model.CrossChainPackageLog, 0)
+	accepted := make([]*model.CrossChainPackageLog, 0)
+	claimed := make([]*model.CrossChainPackageLog, 0)
+	receiveSequences := make(map[uint8]uint64)
+	found := make(map[string]bool)
+	for _, pack := range packages {
+		// the same package may be found by both the oracle sequence gap and the package sequence gap
+		key := fmt.Sprintf("%d_%d", pack.ChannelId, pack.PackageSequence)
+		if pack.ChainId != chainId || found[key] {
+			continue
+		}
+		found[key] = true
+
+		var count int64
+		err := r.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ? and channel_id = ? and package_sequence = ?",
+			pack.ChainId, pack.ChannelId, pack.PackageSequence).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if int64(pack.OracleSequence) >= sequence {
+			pending = append(pending, pack)
+			continue
+		}
+
+		receiveSequence, ok := receiveSequences[pack.ChannelId]
+		if !ok {
+			receiveSequence, err = r.AfcExecutor.GetReceiveSequence(chainId, pack.ChannelId)
+			if err != nil {
+				return fmt.Errorf("get receive sequence error, channel_id=%d, err=%s", pack.ChannelId, err.Error())
+			}
+			receiveSequences[pack.ChannelId] = receiveSequence
+		}
+		if pack.PackageSequence < receiveSequence {
+			accepted = append(accepted, pack)
+		} else {
+			claimed = append(claimed, pack)
+		}
+	}
+
+	if len(pending) > 0 || len(accepted) > 0 {
+		tx := r.DB.Begin()
+		if err := tx.Error; err != nil {
+			return err
+		}
+		for _, pack := range pending {
+			pack.Status = model.PackageStatusConfirmed
+		}
+		// the packages accepted by Axim Chain only fill the gaps in database, they are never claimed again
+		for _, pack := range accepted {
+			pack.Status = model.PackageStatusClaimed
+		}
+		for _, pack := range append(pending, accepted...) {
+			util.Logger.Infof("backfill package, chain_id=%d, channel_id=%d, package_seq=%d, oracle_seq=%d, status=%s, tx_hash=%s",
+				pack.ChainId, pack.ChannelId, pack.PackageSequence, pack.OracleSequence, pack.Status, pack.TxHash)
+			if err := tx.Create(pack).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+	}
+
+	if len(claimed) > 0 {
+		if _, err := r.Inject(claimed, false); err != nil {
+			return fmt.Errorf("inject backfilled packages error, err=%s", err.Error())
+		}
+	}
+	return nil
+}
+
+// searchGap returns the packages of the gap emitted between the packages before and after it, at most
+// BackfillMaxSequences sequences are searched at once. Nothing is returned if the package after the gap is not
+// confirmed yet, since the missing packages may not be confirmed either.
+func (r *Recovery) searchGap(gap relayer.SequenceGap) ([]*model.CrossChainPackageLog, error) {
+	scope := r.DB.Where("chain_id = ?", gap.ChainId)
+	if gap.Column == relayer.GapColumnPackageSequence {
+		scope = scope.Where("channel_id = ?", gap.ChannelId)
+	}
+
+	prev := &model.CrossChainPackageLog{}
+	if err := scope.Where(gap.Column+" = ?", gap.From-1).Order("height asc").First(prev).Error; err != nil {
+		return nil, fmt.Errorf("query package before gap error, err=%s", err.Error())
+	}
+	next := &model.CrossChainPackageLog{}
+	if err := scope.Where(gap.Column+" = ?", gap.To+1).Order("height desc").First(next).Error; err != nil {
+		return nil, fmt.Errorf("query package after gap error, err=%s", err.Error())
+	}
+	if next.Status == model.PackageStatusInit {
+		return nil, nil
+	}
+
+	to := gap.To
+	if to-gap.From >= common.BackfillMaxSequences {
+		to = gap.From + common.BackfillMaxSequences - 1
+	}
+	sequences := make([]uint64, 0, to-gap.From+1)
+	for seq := gap.From; seq <= to; seq++ {
+		sequences = append(sequences, seq)
+	}
+
+	if gap.Column == relayer.GapColumnPackageSequence {
+		return r.AscExecutor.GetPackagesBySequences(prev.Height, next.Height, nil, sequences, []uint8{gap.ChannelId})
+	}
+	return r.AscExecutor.GetPackagesBySequences(prev.Height, next.Height, sequences, nil, nil)
+}
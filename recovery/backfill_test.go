@@ -0,0 +1,103 @@
+package recovery
+
+import (
+	"testing"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/mock"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/relayer"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestRecovery_Backfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+
+	// package 2 of channel 2 is missing but accepted by Axim Chain, package 2 of channel 3 is missing and not
+	// accepted, package 5 of channel 2 of pending oracle sequence 3 is dropped
+	for _, pack := range []*model.CrossChainPackageLog{
+		{ChainId: 96, OracleSequence: 1, PackageSequence: 1, ChannelId: 2, Height: 1, Status: model.PackageStatusClaimed},
+		{ChainId: 96, OracleSequence: 1, PackageSequence: 1, ChannelId: 3, Height: 1, Status: model.PackageStatusClaimed},
+		{ChainId: 96, OracleSequence: 2, PackageSequence: 3, ChannelId: 2, Height: 3, Status: model.PackageStatusClaimed},
+		{ChainId: 96, OracleSequence: 3, PackageSequence: 4, ChannelId: 2, Height: 5, Status: model.PackageStatusConfirmed},
+		{ChainId: 96, OracleSequence: 3, PackageSequence: 6, ChannelId: 2, Height: 5, Status: model.PackageStatusConfirmed},
+		{ChainId: 96, OracleSequence: 3, PackageSequence: 3, ChannelId: 3, Height: 5, Status: model.PackageStatusConfirmed},
+		{ChainId: 96, OracleSequence: 4, PackageSequence: 8, ChannelId: 2, Height: 9, Status: model.PackageStatusInit},
+	} {
+		require.Nil(t, db.Create(pack).Error)
+	}
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetPackagesBySequences(int64(1), int64(3), nil, []uint64{2}, []uint8{2}).Return(
+		[]*model.CrossChainPackageLog{
+			{ChainId: 96, OracleSequence: 1, PackageSequence: 2, ChannelId: 2, Height: 2, TxHash: "tx_hash_2"},
+		}, nil)
+	ascExecutor.EXPECT().GetPackagesBySequences(int64(5), int64(5), nil, []uint64{5}, []uint8{2}).Return(
+		[]*model.CrossChainPackageLog{
+			{ChainId: 96, OracleSequence: 3, PackageSequence: 5, ChannelId: 2, Height: 5, TxHash: "tx_hash_5"},
+			{ChainId: 97, OracleSequence: 3, PackageSequence: 5, ChannelId: 2, Height: 5, TxHash: "tx_hash_5"},
+		}, nil)
+	ascExecutor.EXPECT().GetPackagesBySequences(int64(1), int64(5), nil, []uint64{2}, []uint8{3}).Return(
+		[]*model.CrossChainPackageLog{
+			{ChainId: 96, OracleSequence: 2, PackageSequence: 2, ChannelId: 3, Height: 3, TxHash: "tx_hash_3"},
+		}, nil)
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(3), nil)
+	afcExecutor.EXPECT().GetReceiveSequence(uint16(96), uint8(2)).Times(1).Return(uint64(4), nil)
+	afcExecutor.EXPECT().GetReceiveSequence(uint16(96), uint8(3)).Times(1).Return(uint64(2), nil)
+	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+
+	rel := relayer.NewRelayer(db, afcExecutor, config)
+	gaps, err := rel.FindGaps(96, 3)
+	require.Nil(t, err, "error should be nil")
+	require.Len(t, gaps, 4)
+
+	rec := NewRecovery(db, config, config.ChainConfig, ascExecutor, afcExecutor)
+	err = rec.Backfill(gaps)
+	require.Nil(t, err, "error should be nil")
+
+	// the accepted package keeps its claimed oracle sequence, the package not accepted is attached to the pending
+	// oracle sequence, and the package of the pending oracle sequence is saved as it is, the gap before the
+	// unconfirmed package is not searched
+	for _, expected := range []struct {
+		channelId       uint8
+		packageSequence uint64
+		oracleSequence  uint64
+		status          model.PackageStatus
+	}{
+		{2, 2, 1, model.PackageStatusClaimed},
+		{2, 5, 3, model.PackageStatusConfirmed},
+		{3, 2, 3, model.PackageStatusConfirmed},
+	} {
+		pack := &model.CrossChainPackageLog{}
+		err = db.Where("chain_id = ? and channel_id = ? and package_sequence = ?", 96, expected.channelId,
+			expected.packageSequence).First(pack).Error
+		require.Nil(t, err, "error should be nil")
+		require.Equal(t, expected.oracleSequence, pack.OracleSequence)
+		require.Equal(t, expected.status, pack.Status)
+	}
+
+	gaps, err = rel.FindGaps(96, 3)
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, []relayer.SequenceGap{
+		{ChainId: 96, Column: relayer.GapColumnPackageSequence, ChannelId: 2, From: 7, To: 7},
+	}, gaps)
+
+	// the oracle sequences below the current one are accepted by Axim Chain, they are not searched
+	err = rec.Backfill([]relayer.SequenceGap{
+		{ChainId: 96, Column: relayer.GapColumnOracleSequence, From: 1, To: 2},
+	})
+	require.Nil(t, err, "error should be nil")
+}
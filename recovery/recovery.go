@@ -0,0 +1,225 @@
+package recovery
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/relayer"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// Request describes the ASC tx or block range to recover cross-chain packages from, the chain id can be omitted
+// if there is only one source chain
+type Request struct {
+	ChainId    uint16 `json:"chain_id"`
+	TxHash     string `json:"tx_hash"`
+	FromHeight int64  `json:"from_height"`
+	ToHeight   int64  `json:"to_height"`
+	DryRun     bool   `json:"dry_run"`
+}
+
+func (req *Request) Validate() error {
+	if req.TxHash != "" {
+		if req.FromHeight != 0 || req.ToHeight != 0 {
+			return fmt.Errorf("tx_hash and block range should not be both specified")
+		}
+		return nil
+	}
+
+	if req.FromHeight <= 0 || req.ToHeight < req.FromHeight {
+		return fmt.Errorf("tx_hash or a block range with 0 < from_height <= to_height should be specified")
+	}
+	if req.ToHeight-req.FromHeight >= common.RecoveryMaxBlockRange {
+		return fmt.Errorf("block range should be less than %d blocks", common.RecoveryMaxBlockRange)
+	}
+	return nil
+}
+
+// Result describes the recovered packages and the oracle sequence they are attached to
+type Result struct {
+	DryRun         bool                          `json:"dry_run"`
+	OracleSequence int64                         `json:"oracle_sequence"`
+	Recovered      []*model.CrossChainPackageLog `json:"recovered"`
+	Skipped        []*model.CrossChainPackageLog `json:"skipped"`
+	Payload        string                        `json:"payload"`
+}
+
+type Recovery struct {
+	DB          *gorm.DB
+	Config      *util.Config
+	ChainConfig *util.ChainConfig
+	AscExecutor executor.AscExecutor
+	AfcExecutor executor.AfcExecutor
+}
+
+// NewRecovery returns the recovery instance of the given source chain
+func NewRecovery(db *gorm.DB, cfg *util.Config, chainCfg *util.ChainConfig, ascExecutor executor.AscExecutor,
+	afcExecutor executor.AfcExecutor) *Recovery {
+	return &Recovery{
+		DB:          db,
+		Config:      cfg,
+		ChainConfig: chainCfg,
+		AscExecutor: ascExecutor,
+		AfcExecutor: afcExecutor,
+	}
+}
+
+// FindRecovery returns the recovery of the given chain id, the chain id can be 0 if there is only one recovery
+func FindRecovery(recoveries []*Recovery, chainId uint16) *Recovery {
+	for _, rec := range recoveries {
+		if chainId == rec.ChainConfig.ASCChainId || (chainId == 0 && len(recoveries) == 1) {
+			return rec
+		}
+	}
+	return nil
+}
+
+// Recover re-fetches the cross-chain packages of the requested tx or block range from ASC, and attaches the
+// packages missing in database to the next pending oracle sequence
+func (r *Recovery) Recover(req *Request) (*Result, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.ChainId != 0 && req.ChainId != r.ChainConfig.ASCChainId {
+		return nil, fmt.Errorf("chain id mismatch, expected=%d, actual=%d", r.ChainConfig.ASCChainId, req.ChainId)
+	}
+
+	var packages []*model.CrossChainPackageLog
+	var err error
+	if req.TxHash != "" {
+		packages, err = r.AscExecutor.GetPackagesByTx(req.TxHash)
+	} else {
+		packages, err = r.AscExecutor.GetPackagesByRange(req.FromHeight, req.ToHeight)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get packages error, err=%s", err.Error())
+	}
+
+	return r.Inject(packages, req.DryRun)
+}
+
+// Inject attaches the given packages which are not in database to the next pending oracle sequence and saves them
+// as confirmed packages. If dryRun is true, nothing will be saved.
+func (r *Recovery) Inject(packages []*model.CrossChainPackageLog, dryRun bool) (*Result, error) {
+	chainId := r.ChainConfig.ASCChainId
+
+	sequence, err := r.AfcExecutor.GetCurrentSequence(chainId)
+	if err != nil {
+		return nil, fmt.Errorf("get current sequence error, err=%s", err.Error())
+	}
+
+	prophecy, err := r.AfcExecutor.GetProphecy(chainId, sequence)
+	if err != nil {
+		return nil, fmt.Errorf("get prophecy error, err=%s", err.Error())
+	}
+	validatorAddress, err := r.AfcExecutor.GetAddress()
+	if err != nil {
+		return nil, fmt.Errorf("get validator address error, err=%s", err.Error())
+	}
+	if prophecy != nil && prophecy.ValidatorClaims != nil && prophecy.ValidatorClaims[validatorAddress.String()] != "" {
+		return nil, fmt.Errorf("oracle sequence %d is already claimed, please retry later", sequence)
+	}
+
+	result := &Result{
+		DryRun:         dryRun,
+		OracleSequence: sequence,
+		Recovered:      make([]*model.CrossChainPackageLog, 0),
+		Skipped:        make([]*model.CrossChainPackageLog, 0),
+	}
+
+	for _, pack := range packages {
+		if pack.ChainId != chainId {
+			result.Skipped = append(result.Skipped, pack)
+			continue
+		}
+
+		var count int64
+		err := r.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ? and channel_id = ? and package_sequence = ?",
+			pack.ChainId, pack.ChannelId, pack.PackageSequence).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			result.Skipped = append(result.Skipped, pack)
+			continue
+		}
+
+		util.Logger.Infof("recover package, chain_id=%d, channel_id=%d, package_seq=%d, oracle_seq=%d, tx_hash=%s",
+			pack.ChainId, pack.ChannelId, pack.PackageSequence, pack.OracleSequence, pack.TxHash)
+		pack.OracleSequence = uint64(sequence)
+		pack.Status = model.PackageStatusConfirmed
+		result.Recovered = append(result.Recovered, pack)
+	}
+
+	if len(result.Recovered) == 0 {
+		return result, nil
+	}
+
+	tx := r.DB.Begin()
+	if err := tx.Error; err != nil {
+		return nil, err
+	}
+
+	// the packages of the pending oracle sequence are locked until the recovered ones are saved, so that the relayer
+	// can not claim the oracle sequence between the check below and the commit. SQLite locks the whole database
+	// on write instead.
+	query := tx.Where("oracle_sequence = ? and chain_id = ? and status in (?)", sequence, chainId,
+		[]model.PackageStatus{model.PackageStatusConfirmed, model.PackageStatusClaimed})
+	if r.Config.DBConfig.Dialect == common.DBDialectMysql {
+		query = query.Set("gorm:query_option", "FOR UPDATE")
+	}
+	claimLogs := make([]*model.CrossChainPackageLog, 0)
+	err = query.Order("height asc, tx_index asc").Find(&claimLogs).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(claimLogs) == 0 {
+		tx.Rollback()
+		return nil, fmt.Errorf("no confirmed packages of oracle sequence %d found, please retry when they are confirmed", sequence)
+	}
+	for _, claimLog := range claimLogs {
+		if claimLog.Status == model.PackageStatusClaimed {
+			tx.Rollback()
+			return nil, fmt.Errorf("oracle sequence %d is already claimed, please retry later", sequence)
+		}
+	}
+
+	// the recovered packages are emitted before the packages of the pending oracle sequence, so they are
+	// claimed first in the same order as the relayer does
+	sort.SliceStable(result.Recovered, func(i, j int) bool {
+		if result.Recovered[i].Height != result.Recovered[j].Height {
+			return result.Recovered[i].Height < result.Recovered[j].Height
+		}
+		return result.Recovered[i].TxIndex < result.Recovered[j].TxIndex
+	})
+	claimLogs = append(append([]*model.CrossChainPackageLog{}, result.Recovered...), claimLogs...)
+	encodedPackages, err := relayer.EncodePackages(claimLogs)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	result.Payload = hex.EncodeToString(encodedPackages)
+
+	if dryRun {
+		tx.Rollback()
+		return result, nil
+	}
+
+	for _, pack := range result.Recovered {
+		if err := tx.Create(pack).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package recovery
+
+import (
+	"testing"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/golang/mock/gomock"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/mock"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestRequest_Validate(t *testing.T) {
+	cases := []struct {
+		req    *Request
+		result bool
+	}{
+		{&Request{}, false},
+		{&Request{TxHash: "0x1"}, true},
+		{&Request{TxHash: "0x1", FromHeight: 1, ToHeight: 2}, false},
+		{&Request{FromHeight: 2, ToHeight: 1}, false},
+		{&Request{FromHeight: 1, ToHeight: 1}, true},
+		{&Request{FromHeight: 1, ToHeight: 5001}, false},
+	}
+
+	for _, c := range cases {
+		err := c.req.Validate()
+		if c.result {
+			require.Nil(t, err, "error should be nil")
+		} else {
+			require.NotNil(t, err, "error should not be nil")
+		}
+	}
+}
+
+func TestRecovery_Recover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+
+	ascExecutor := mock.NewMockAscExecutor(ctrl)
+	ascExecutor.EXPECT().GetPackagesByRange(int64(1), int64(2)).AnyTimes().DoAndReturn(
+		func(fromHeight, toHeight int64) ([]*model.CrossChainPackageLog, error) {
+			return []*model.CrossChainPackageLog{
+				{ChainId: 96, OracleSequence: 1, PackageSequence: 1, ChannelId: 2, Height: 1, TxHash: "tx_hash_1"},
+				{ChainId: 96, OracleSequence: 1, PackageSequence: 2, ChannelId: 2, Height: 1, TxHash: "tx_hash_2"},
+			}, nil
+		})
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(3), nil)
+	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+
+	// package 2 is present and package 3 is in the pending oracle sequence
+	db.Create(&model.CrossChainPackageLog{
+		ChainId: 96, OracleSequence: 1, PackageSequence: 2, ChannelId: 2, Height: 1,
+		Status: model.PackageStatusClaimed, TxHash: "tx_hash_2",
+	})
+	db.Create(&model.CrossChainPackageLog{
+		ChainId: 96, OracleSequence: 3, PackageSequence: 3, ChannelId: 2, Height: 5,
+		Status: model.PackageStatusConfirmed, TxHash: "tx_hash_3",
+	})
+
+	rec := NewRecovery(db, config, config.ChainConfig, ascExecutor, afcExecutor)
+
+	result, err := rec.Recover(&Request{FromHeight: 1, ToHeight: 2, DryRun: true})
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, int64(3), result.OracleSequence)
+	require.Len(t, result.Recovered, 1)
+	require.Len(t, result.Skipped, 1)
+	require.NotEmpty(t, result.Payload)
+
+	var count int64
+	db.Model(model.CrossChainPackageLog{}).Where("oracle_sequence = ?", 3).Count(&count)
+	require.Equal(t, int64(1), count, "dry run should not save packages")
+
+	result, err = rec.Recover(&Request{FromHeight: 1, ToHeight: 2})
+	require.Nil(t, err, "error should be nil")
+	require.Len(t, result.Recovered, 1)
+
+	recovered := &model.CrossChainPackageLog{}
+	err = db.Where("package_sequence = ? and channel_id = ?", 1, 2).First(recovered).Error
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, uint64(3), recovered.OracleSequence)
+	require.Equal(t, model.PackageStatusConfirmed, recovered.Status)
+}
@@ -0,0 +1,256 @@
+package relayer
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+const (
+	GapColumnOracleSequence  = "oracle_sequence"
+	GapColumnPackageSequence = "package_sequence"
+)
+
+// SequenceGap is a range of sequences missing in database, they are either the oracle sequences of the source chain
+// or the package sequences of one channel
+type SequenceGap struct {
+	ChainId uint16
+	// Column is oracle_sequence or package_sequence, ChannelId is only set for package_sequence
+	Column    string
+	ChannelId uint8
+	From      uint64
+	To        uint64
+}
+
+func (g SequenceGap) String() string {
+	seqs := fmt.Sprintf("%d", g.From)
+	if g.To > g.From {
+		seqs = fmt.Sprintf("%d-%d", g.From, g.To)
+	}
+	if g.Column == GapColumnPackageSequence {
+		return fmt.Sprintf("channel_id=%d, package_sequence=%s", g.ChannelId, seqs)
+	}
+	return fmt.Sprintf("oracle_sequence=%s", seqs)
+}
+
+func formatGaps(gaps []SequenceGap) string {
+	strs := make([]string, 0, len(gaps))
+	for _, gap := range gaps {
+		strs = append(strs, gap.String())
+	}
+	return strings.Join(strs, "; ")
+}
+
+// Backfiller recovers the packages of the sequence gaps from the source chain
+type Backfiller interface {
+	Backfill(gaps []SequenceGap) error
+}
+
+// DetectGaps checks the sequences of all the packages of the given source chain in database every GapDetectInterval,
+// and sends alert until the gaps are filled. The gaps are backfilled if there is a backfiller of the chain.
+func (r *Relayer) DetectGaps(chainConfig *util.ChainConfig) {
+	chainId := chainConfig.ASCChainId
+	for {
+		gaps, err := r.findCurrentGaps(chainId)
+		if err != nil {
+			util.Logger.Errorf("find sequence gaps error, chain_id=%d, err=%s", chainId, err.Error())
+		} else if len(gaps) > 0 {
+			r.alertGaps(chainId, gaps)
+			if backfiller := r.Backfillers[chainId]; backfiller != nil {
+				if err := backfiller.Backfill(gaps); err != nil {
+					util.Logger.Errorf("backfill sequence gaps error, chain_id=%d, err=%s", chainId, err.Error())
+				}
+			}
+		} else {
+			alertMsg := fmt.Sprintf("[%s] resolved: no sequences are missing in database, chain_id=%d",
+				r.Config.Alert().Moniker, chainId)
+			alert.Resolve(alert.ChainDedupKey(alert.IncidentDedupKeySequenceGap, chainId), alertMsg)
+		}
+
+		time.Sleep(common.GapDetectInterval)
+	}
+}
+
+func (r *Relayer) alertGaps(chainId uint16, gaps []SequenceGap) {
+	util.Logger.Errorf("sequences missing in database, chain_id=%d, gaps=%s", chainId, formatGaps(gaps))
+
+	alertMsg := fmt.Sprintf("[%s] sequences missing in database, the packages should be recovered, chain_id=%d, gaps=%s",
+		r.Config.Alert().Moniker, chainId, formatGaps(gaps))
+	alert.Fire(alert.ChainDedupKey(alert.IncidentDedupKeySequenceGap, chainId), util.SeverityCritical, alertMsg)
+}
+
+// findCurrentGaps returns the gaps of the given source chain from the current oracle sequence of Axim Chain
+func (r *Relayer) findCurrentGaps(chainId uint16) ([]SequenceGap, error) {
+	sequence, err := r.AFCExecutor.GetCurrentSequence(chainId)
+	if err != nil {
+		return nil, fmt.Errorf("get current sequence error, err=%s", err.Error())
+	}
+	return r.FindGaps(chainId, sequence)
+}
+
+// FindGaps returns the oracle sequences from the given current oracle sequence and the package sequences of every
+// channel which are missing between the packages of the given source chain in database. The oracle sequences below
+// the current one are accepted by Axim Chain already, the packages missing there are found by the package sequences.
+func (r *Relayer) FindGaps(chainId uint16, sequence int64) ([]SequenceGap, error) {
+	oracleGaps, err := r.findGaps(chainId, GapColumnOracleSequence, nil)
+	if err != nil {
+		return nil, err
+	}
+	gaps := make([]SequenceGap, 0, len(oracleGaps))
+	for _, gap := range oracleGaps {
+		if int64(gap.To) < sequence {
+			continue
+		}
+		if int64(gap.From) < sequence {
+			gap.From = uint64(sequence)
+		}
+		gaps = append(gaps, gap)
+	}
+
+	var channelIds []uint8
+	err = r.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ?", chainId).
+		Order("channel_id asc").Pluck("distinct channel_id", &channelIds).Error
+	if err != nil {
+		return nil, fmt.Errorf("query channels error, err=%s", err.Error())
+	}
+	for idx := range channelIds {
+		channelGaps, err := r.findGaps(chainId, GapColumnPackageSequence, &channelIds[idx])
+		if err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, channelGaps...)
+	}
+	return gaps, nil
+}
+
+// findGaps finds the rows whose next sequence is missing, and the gap lasts until the lowest sequence after them.
+// channelId is nil for the oracle sequences.
+func (r *Relayer) findGaps(chainId uint16, column string, channelId *uint8) ([]SequenceGap, error) {
+	scopeColumns := []string{"chain_id"}
+	args := []interface{}{chainId}
+	if channelId != nil {
+		scopeColumns = append(scopeColumns, "channel_id")
+		args = append(args, *channelId)
+	}
+	joinConds := []string{fmt.Sprintf("b.%s = a.%s + 1", column, column)}
+	nextConds := []string{fmt.Sprintf("c.%s > a.%s", column, column)}
+	whereConds := []string{"b.id is null"}
+	for _, scopeColumn := range scopeColumns {
+		joinConds = append(joinConds, fmt.Sprintf("b.%s = a.%s", scopeColumn, scopeColumn))
+		nextConds = append(nextConds, fmt.Sprintf("c.%s = a.%s", scopeColumn, scopeColumn))
+		whereConds = append(whereConds, fmt.Sprintf("a.%s = ?", scopeColumn))
+	}
+
+	table := model.CrossChainPackageLog{}.TableName()
+	var rows []struct {
+		StartSeq uint64
+		NextSeq  sql.NullInt64
+	}
+	err := r.DB.Table(table+" a").
+		Select(fmt.Sprintf("distinct a.%s as start_seq, (select min(c.%s) from %s c where %s) as next_seq",
+			column, column, table, strings.Join(nextConds, " and "))).
+		Joins(fmt.Sprintf("left join %s b on %s", table, strings.Join(joinConds, " and "))).
+		Where(strings.Join(whereConds, " and "), args...).
+		Order("start_seq asc").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("query %s gaps error, err=%s", column, err.Error())
+	}
+
+	gaps := make([]SequenceGap, 0)
+	for _, row := range rows {
+		// the highest sequence is not followed by any gap
+		if !row.NextSeq.Valid {
+			continue
+		}
+		gap := SequenceGap{ChainId: chainId, Column: column, From: row.StartSeq + 1, To: uint64(row.NextSeq.Int64) - 1}
+		if channelId != nil {
+			gap.ChannelId = *channelId
+		}
+		gaps = append(gaps, gap)
+	}
+	return gaps, nil
+}
+
+// checkClaimGaps returns the gaps between the packages to claim of the oracle sequence and the packages of the
+// previous oracle sequences, the packages should not be claimed if there is any gap. Only the oracle sequences from
+// the given current one of Axim Chain are checked, since the earlier ones are accepted already.
+func (r *Relayer) checkClaimGaps(chainId uint16, current, sequence int64, claimLogs []*model.CrossChainPackageLog) ([]SequenceGap, error) {
+	gaps := make([]SequenceGap, 0)
+
+	if sequence > current {
+		var prevOracle struct {
+			Seq sql.NullInt64
+		}
+		err := r.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ? and oracle_sequence >= ? and oracle_sequence < ?",
+			chainId, current, sequence).Select("max(oracle_sequence) as seq").Scan(&prevOracle).Error
+		if err != nil {
+			return nil, fmt.Errorf("query previous oracle sequence error, err=%s", err.Error())
+		}
+		from := current
+		if prevOracle.Seq.Valid {
+			from = prevOracle.Seq.Int64 + 1
+		}
+		if from < sequence {
+			gaps = append(gaps, SequenceGap{ChainId: chainId, Column: GapColumnOracleSequence,
+				From: uint64(from), To: uint64(sequence) - 1})
+		}
+	}
+
+	// the package sequences of every channel should follow those of the previous oracle sequences, the packages
+	// recovered to this oracle sequence may fill the gaps before them
+	lowest := make(map[uint8]uint64)
+	highest := make(map[uint8]uint64)
+	channelIds := make([]uint8, 0)
+	for _, claimLog := range claimLogs {
+		lo, ok := lowest[claimLog.ChannelId]
+		if !ok {
+			channelIds = append(channelIds, claimLog.ChannelId)
+		}
+		if !ok || claimLog.PackageSequence < lo {
+			lowest[claimLog.ChannelId] = claimLog.PackageSequence
+		}
+		if claimLog.PackageSequence > highest[claimLog.ChannelId] {
+			highest[claimLog.ChannelId] = claimLog.PackageSequence
+		}
+	}
+	sort.Slice(channelIds, func(i, j int) bool { return channelIds[i] < channelIds[j] })
+
+	for _, channelId := range channelIds {
+		var prevPackage struct {
+			Seq sql.NullInt64
+		}
+		err := r.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ? and channel_id = ? and oracle_sequence <= ? and package_sequence < ?",
+			chainId, channelId, sequence, lowest[channelId]).Select("max(package_sequence) as seq").Scan(&prevPackage).Error
+		if err != nil {
+			return nil, fmt.Errorf("query previous package sequence error, err=%s", err.Error())
+		}
+
+		var seqs []uint64
+		err = r.DB.Model(model.CrossChainPackageLog{}).Where("chain_id = ? and channel_id = ? and oracle_sequence <= ? and package_sequence between ? and ?",
+			chainId, channelId, sequence, lowest[channelId], highest[channelId]).
+			Order("package_sequence asc").Pluck("distinct package_sequence", &seqs).Error
+		if err != nil {
+			return nil, fmt.Errorf("query package sequences error, err=%s", err.Error())
+		}
+
+		expected := lowest[channelId]
+		if prevPackage.Seq.Valid {
+			expected = uint64(prevPackage.Seq.Int64) + 1
+		}
+		for _, seq := range seqs {
+			if seq > expected {
+				gaps = append(gaps, SequenceGap{ChainId: chainId, Column: GapColumnPackageSequence, ChannelId: channelId,
+					From: expected, To: seq - 1})
+			}
+			expected = seq + 1
+		}
+	}
+	return gaps, nil
+}
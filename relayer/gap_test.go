@@ -0,0 +1,172 @@
+package relayer
+
+import (
+	"testing"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/mock"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestRelayer_FindGaps(t *testing.T) {
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	packages := []struct {
+		chainId         uint16
+		oracleSequence  uint64
+		channelId       uint8
+		packageSequence uint64
+	}{
+		{96, 1, 2, 1},
+		{96, 1, 2, 2},
+		{96, 2, 2, 5},
+		{96, 4, 2, 6},
+		{96, 4, 3, 1},
+		{96, 7, 2, 9},
+		{96, 7, 3, 2},
+		{97, 1, 2, 1},
+		{97, 3, 2, 3},
+	}
+	for _, pack := range packages {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         pack.chainId,
+			OracleSequence:  pack.oracleSequence,
+			PackageSequence: pack.packageSequence,
+			ChannelId:       pack.channelId,
+			Status:          model.PackageStatusClaimed,
+		})
+	}
+
+	relayer := NewRelayer(db, nil, config)
+	gaps, err := relayer.FindGaps(96, 0)
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, []SequenceGap{
+		{ChainId: 96, Column: GapColumnOracleSequence, From: 3, To: 3},
+		{ChainId: 96, Column: GapColumnOracleSequence, From: 5, To: 6},
+		{ChainId: 96, Column: GapColumnPackageSequence, ChannelId: 2, From: 3, To: 4},
+		{ChainId: 96, Column: GapColumnPackageSequence, ChannelId: 2, From: 7, To: 8},
+	}, gaps)
+	require.Equal(t, "oracle_sequence=3; oracle_sequence=5-6; channel_id=2, package_sequence=3-4; channel_id=2, package_sequence=7-8",
+		formatGaps(gaps))
+
+	// the oracle sequences below the current one are not reported
+	gaps, err = relayer.FindGaps(96, 6)
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, []SequenceGap{
+		{ChainId: 96, Column: GapColumnOracleSequence, From: 6, To: 6},
+		{ChainId: 96, Column: GapColumnPackageSequence, ChannelId: 2, From: 3, To: 4},
+		{ChainId: 96, Column: GapColumnPackageSequence, ChannelId: 2, From: 7, To: 8},
+	}, gaps)
+
+	gaps, err = relayer.FindGaps(98, 0)
+	require.Nil(t, err, "error should be nil")
+	require.Empty(t, gaps)
+}
+
+func TestRelayer_process_sequenceGap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(2), nil)
+	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("claim_tx_hash", nil)
+
+	relayer := NewRelayer(db, afcExecutor, config)
+	for _, pack := range []struct {
+		oracleSequence  uint64
+		packageSequence uint64
+		status          model.PackageStatus
+	}{
+		{1, 1, model.PackageStatusClaimed},
+		{2, 2, model.PackageStatusConfirmed},
+		{2, 4, model.PackageStatusConfirmed},
+	} {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  pack.oracleSequence,
+			PackageSequence: pack.packageSequence,
+			ChannelId:       2,
+			Height:          2,
+			Status:          pack.status,
+			TxHash:          "tx_hash",
+		})
+	}
+
+	err = relayer.process(96)
+	require.NotNil(t, err, "error should not be nil")
+	require.Contains(t, err.Error(), "channel_id=2, package_sequence=3")
+
+	// the missing package is recovered to the oracle sequence
+	db.Create(&model.CrossChainPackageLog{
+		ChainId:         96,
+		OracleSequence:  2,
+		PackageSequence: 3,
+		ChannelId:       2,
+		Height:          1,
+		Status:          model.PackageStatusConfirmed,
+		TxHash:          "recovered_tx_hash",
+	})
+	err = relayer.process(96)
+	require.Nil(t, err, "error should be nil")
+}
+
+func TestRelayer_process_claimedSequenceMissing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(3), nil)
+	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), uint64(3), gomock.Any()).Times(1).Return("claim_tx_hash", nil)
+
+	// oracle sequence 2 is accepted by Axim Chain but missing in database, without any package sequence missing
+	relayer := NewRelayer(db, afcExecutor, config)
+	for _, pack := range []struct {
+		oracleSequence  uint64
+		packageSequence uint64
+		status          model.PackageStatus
+	}{
+		{1, 1, model.PackageStatusClaimed},
+		{3, 2, model.PackageStatusConfirmed},
+	} {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  pack.oracleSequence,
+			PackageSequence: pack.packageSequence,
+			ChannelId:       2,
+			Height:          2,
+			Status:          pack.status,
+			TxHash:          "tx_hash",
+		})
+	}
+
+	gaps, err := relayer.FindGaps(96, 3)
+	require.Nil(t, err, "error should be nil")
+	require.Empty(t, gaps)
+
+	err = relayer.process(96)
+	require.Nil(t, err, "error should be nil")
+}
@@ -2,7 +2,10 @@ package relayer
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aximchain/go-sdk/common/types"
@@ -10,16 +13,30 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/jinzhu/gorm"
 
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
 	"github.com/Sotatek-huytran2/oracle-relayer/common"
 	"github.com/Sotatek-huytran2/oracle-relayer/executor"
+	"github.com/Sotatek-huytran2/oracle-relayer/metrics"
 	"github.com/Sotatek-huytran2/oracle-relayer/model"
 	"github.com/Sotatek-huytran2/oracle-relayer/util"
 )
 
+var (
+	errNoPackages     = errors.New("no packages found")
+	errAlreadyClaimed = errors.New("already claimed")
+)
+
 type Relayer struct {
 	DB          *gorm.DB
 	AFCExecutor executor.AfcExecutor
 	Config      *util.Config
+
+	// Backfillers recover the packages missing in database by the source chain id, they should be set before Main
+	Backfillers map[uint16]Backfiller
+
+	// mismatchMtx guards mismatches, the oracle sequences of every source chain whose claim mismatch alerts are fired
+	mismatchMtx sync.Mutex
+	mismatches  map[uint16]map[int64]bool
 }
 
 // NewRelayer returns the relayer instance
@@ -28,22 +45,32 @@ func NewRelayer(db *gorm.DB, afcExecutor executor.AfcExecutor, cfg *util.Config)
 		DB:          db,
 		AFCExecutor: afcExecutor,
 		Config:      cfg,
+		Backfillers: make(map[uint16]Backfiller),
+		mismatches:  make(map[uint16]map[int64]bool),
 	}
 }
 
-// Main starts the routines of relayer
+// Main starts the routines of relayer for every source chain
 func (r *Relayer) Main() {
-	go r.RelayPackages()
+	for _, chainConfig := range r.Config.ChainConfigs {
+		go r.RelayPackages(chainConfig)
+
+		go r.Alert(chainConfig)
 
-	go r.Alert()
+		go r.DetectGaps(chainConfig)
+	}
+
+	if r.Config.ChainConfig.AFCBroadcastMode == util.BroadcastModeSync {
+		go r.TrackClaimTxs()
+	}
 }
 
-// RelayPackages starts the main routine for processing the cross-chain packages
-func (r *Relayer) RelayPackages() {
+// RelayPackages starts the main routine for processing the cross-chain packages of the given source chain
+func (r *Relayer) RelayPackages(chainConfig *util.ChainConfig) {
 	for {
-		err := r.process(r.Config.ChainConfig.ASCChainId)
+		err := r.process(chainConfig.ASCChainId)
 		if err != nil {
-			time.Sleep(time.Duration(r.Config.ChainConfig.RelayInterval) * time.Millisecond)
+			time.Sleep(time.Duration(r.Config.RelayInterval(chainConfig)) * time.Millisecond)
 		}
 	}
 }
@@ -58,17 +85,84 @@ func (r *Relayer) process(chainId uint16) error {
 	}
 
 	util.Logger.Infof("current sequence, chain_id=%d, seq=%d", chainId, sequence)
+	chainLabel := strconv.Itoa(int(chainId))
+	metrics.AfcOracleSequence.WithLabelValues(chainLabel).Set(float64(sequence))
+
+	window := int64(1)
+	if chainConfig := r.chainConfig(chainId); chainConfig != nil && chainConfig.RelayWindow > 1 {
+		window = chainConfig.RelayWindow
+	}
+	if window == 1 {
+		return r.claim(chainId, sequence, sequence)
+	}
+
+	// the claims of the following sequences are broadcast while the previous ones are pending, the pipeline stops
+	// at the first sequence which is neither confirmed nor claimed by us, since the later claims would fail until it
+	// is claimed, and at the first failed claim, the account sequence is fetched again by the next claim
+	claimed := 0
+	for seq := sequence; seq < sequence+window; seq++ {
+		err := r.claim(chainId, sequence, seq)
+		if err == errAlreadyClaimed {
+			continue
+		}
+		if err == errNoPackages {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		claimed++
+	}
+	if claimed == 0 {
+		return errNoPackages
+	}
+	return nil
+}
+
+// chainConfig returns the config of the given source chain
+func (r *Relayer) chainConfig(chainId uint16) *util.ChainConfig {
+	for _, chainConfig := range r.Config.ChainConfigs {
+		if chainConfig.ASCChainId == chainId {
+			return chainConfig
+		}
+	}
+	return nil
+}
 
+// claim claims the confirmed packages of the given oracle sequence, current is the current oracle sequence of Axim Chain
+func (r *Relayer) claim(chainId uint16, current, sequence int64) error {
+	chainLabel := strconv.Itoa(int(chainId))
 	claimLogs := make([]*model.CrossChainPackageLog, 0)
-	err = r.DB.Where("oracle_sequence = ? and chain_id = ? and status = ?",
-		sequence, chainId, model.PackageStatusConfirmed).Order("tx_index asc").Find(&claimLogs).Error
+	err := r.DB.Where("oracle_sequence = ? and chain_id = ? and status = ?",
+		sequence, chainId, model.PackageStatusConfirmed).Order("height asc, tx_index asc").Find(&claimLogs).Error
 	if err != nil {
 		util.Logger.Errorf("query claim log error: err=%s", err.Error())
 		return err
 	}
 
 	if len(claimLogs) == 0 {
-		return fmt.Errorf("no packages found")
+		// the packages of our pending claim are not confirmed any more
+		var count int64
+		err := r.DB.Model(model.CrossChainPackageLog{}).Where("oracle_sequence = ? and chain_id = ? and status = ?",
+			sequence, chainId, model.PackageStatusClaimed).Count(&count).Error
+		if err != nil {
+			util.Logger.Errorf("query claimed log error: err=%s", err.Error())
+			return err
+		}
+		if count > 0 {
+			return errAlreadyClaimed
+		}
+		return errNoPackages
+	}
+
+	gaps, err := r.checkClaimGaps(chainId, current, sequence, claimLogs)
+	if err != nil {
+		util.Logger.Errorf("check sequence gaps error: err=%s", err.Error())
+		return err
+	}
+	if len(gaps) > 0 {
+		r.alertGaps(chainId, gaps)
+		return fmt.Errorf("sequences missing, chain_id=%d, seq=%d, gaps=%s", chainId, sequence, formatGaps(gaps))
 	}
 
 	prophecy, err := r.AFCExecutor.GetProphecy(chainId, sequence)
@@ -77,16 +171,80 @@ func (r *Relayer) process(chainId uint16) error {
 		return err
 	}
 
-	validatorAddress := r.AFCExecutor.GetAddress()
+	validatorAddress, err := r.AFCExecutor.GetAddress()
+	if err != nil {
+		util.Logger.Errorf("get validator address error: err=%s", err.Error())
+		return err
+	}
+
+	encodedPackages, err := EncodePackages(claimLogs)
+	if err != nil {
+		return err
+	}
+	// a claim differing from those of the other validators means that the packages in database may be wrong
+	r.verifyClaims(chainId, sequence, prophecy, validatorAddress.String(), encodedPackages)
+
 	if prophecy != nil && prophecy.ValidatorClaims != nil && prophecy.ValidatorClaims[validatorAddress.String()] != "" {
-		return fmt.Errorf("already claimed")
+		return errAlreadyClaimed
 	}
 
+	util.Logger.Infof("claim, chain_id=%d, seq=%d, payload=%s",
+		chainId, sequence, hex.EncodeToString(encodedPackages))
+	claimStart := time.Now()
+	txHash, err := r.AFCExecutor.Claim(chainId, uint64(sequence), encodedPackages)
+	if err != nil {
+		observeClaim(chainLabel, metrics.ClaimResultFailure, claimStart)
+		util.Logger.Errorf("claim error: err=%s", err.Error())
+		return err
+	}
+	observeClaim(chainLabel, metrics.ClaimResultSuccess, claimStart)
+
+	claimIds := make([]int64, 0, len(claimLogs))
+	for _, claimLog := range claimLogs {
+		claimIds = append(claimIds, claimLog.Id)
+	}
+
+	tx := r.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+	err = tx.Model(model.CrossChainPackageLog{}).Where("id in (?)", claimIds).Update(map[string]interface{}{
+		"status":        model.PackageStatusClaimed,
+		"claim_tx_hash": txHash,
+		"update_time":   time.Now().Unix(),
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		util.Logger.Errorf("update CrossChainPackageLog error, err=%s", err.Error())
+		return err
+	}
+	// the claim tx broadcast in sync mode is tracked until it is included in a block
+	if r.Config.ChainConfig.AFCBroadcastMode == util.BroadcastModeSync {
+		err = tx.Create(&model.ClaimTxLog{
+			ChainId:        chainId,
+			OracleSequence: uint64(sequence),
+			TxHash:         txHash,
+			Status:         model.ClaimTxStatusPending,
+		}).Error
+		if err != nil {
+			tx.Rollback()
+			util.Logger.Errorf("create ClaimTxLog error, err=%s", err.Error())
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// EncodePackages returns the rlp encoded packages of the given package logs which will be claimed to Axim Chain
+func EncodePackages(claimLogs []*model.CrossChainPackageLog) ([]byte, error) {
 	packages := make(msg.Packages, 0, len(claimLogs))
 	for _, claimLog := range claimLogs {
 		payload, err := hex.DecodeString(claimLog.PayLoad)
 		if err != nil {
-			return fmt.Errorf("decode payload error, payload=%s", claimLog.PayLoad)
+			return nil, fmt.Errorf("decode payload error, payload=%s", claimLog.PayLoad)
 		}
 
 		pack := msg.Package{
@@ -99,59 +257,74 @@ func (r *Relayer) process(chainId uint16) error {
 
 	encodedPackages, err := rlp.EncodeToBytes(packages)
 	if err != nil {
-		return fmt.Errorf("encode packages error, err=%s", err.Error())
+		return nil, fmt.Errorf("encode packages error, err=%s", err.Error())
 	}
+	return encodedPackages, nil
+}
 
-	util.Logger.Infof("claim, chain_id=%d, seq=%d, payload=%s",
-		chainId, sequence, hex.EncodeToString(encodedPackages))
-	txHash, err := r.AFCExecutor.Claim(chainId, uint64(sequence), encodedPackages)
-	if err != nil {
-		util.Logger.Errorf("claim error: err=%s", err.Error())
-		return err
+// GetOldestConfirmedPackage returns the confirmed but unclaimed package with the lowest oracle sequence
+// not less than the given sequence, nil will be returned if there is no such package
+func (r *Relayer) GetOldestConfirmedPackage(chainId uint16, sequence int64) (*model.CrossChainPackageLog, error) {
+	claimLog := &model.CrossChainPackageLog{}
+	err := r.DB.Where("chain_id = ? and status = ? and oracle_sequence >= ?",
+		chainId, model.PackageStatusConfirmed, sequence).Order("oracle_sequence asc").First(claimLog).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
 	}
-
-	err = r.DB.Model(model.CrossChainPackageLog{}).Where("oracle_sequence = ? and chain_id = ?", sequence, chainId).Update(map[string]interface{}{
-		"status":        model.PackageStatusClaimed,
-		"claim_tx_hash": txHash,
-		"update_time":   time.Now().Unix(),
-	}).Error
 	if err != nil {
-		util.Logger.Errorf("update CrossChainPackageLog error, err=%s", err.Error())
+		return nil, err
 	}
-	return err
+	return claimLog, nil
+}
+
+func observeClaim(chainLabel, result string, start time.Time) {
+	metrics.ClaimCount.WithLabelValues(chainLabel, result).Inc()
+	metrics.ClaimLatency.WithLabelValues(chainLabel, result).Observe(time.Since(start).Seconds())
 }
 
-// Alert sends alert to tg group if there is any package delayed
-func (r *Relayer) Alert() {
+// Alert sends alert if there is any package of the given source chain delayed, and resolves it once the delay clears
+func (r *Relayer) Alert(chainConfig *util.ChainConfig) {
+	chainId := chainConfig.ASCChainId
 	for {
 		time.Sleep(common.PackageDelayAlertInterval)
 
-		sequence, err := r.AFCExecutor.GetCurrentSequence(r.Config.ChainConfig.ASCChainId)
+		sequence, err := r.AFCExecutor.GetCurrentSequence(chainId)
 		if err != nil {
 			util.Logger.Errorf("get current sequence error: chainId=%d, err=%s",
-				r.Config.ChainConfig.ASCChainId, err.Error())
+				chainId, err.Error())
 			continue
 		}
 
-		claimLog := &model.CrossChainPackageLog{}
+		// the claims submitted by the other validators after ours are verified as well, and the mismatches of the
+		// accepted sequences are resolved
+		r.resolveAcceptedMismatches(chainId, sequence)
+		if _, err := r.verifyPendingClaim(chainId, sequence); err != nil {
+			util.Logger.Errorf("verify pending claim error, chain_id=%d, seq=%d, err=%s", chainId, sequence, err.Error())
+		}
 
-		err = r.DB.Where("chain_id = ? and status = ? and oracle_sequence >= ?",
-			r.Config.ChainConfig.ASCChainId, model.PackageStatusConfirmed, sequence).Order("oracle_sequence asc").First(&claimLog).Error
-		if err != nil && err != gorm.ErrRecordNotFound {
+		claimLog, err := r.GetOldestConfirmedPackage(chainId, sequence)
+		if err != nil {
 			util.Logger.Errorf("query claim log error: err=%s", err.Error())
 			continue
 		}
 
-		if claimLog.Id == 0 {
-			continue
-		}
+		dedupKey := alert.ChainDedupKey(alert.IncidentDedupKeyRelayError, chainId)
+		alertConfig := r.Config.Alert()
+		if claimLog != nil && time.Now().Unix()-claimLog.UpdateTime > alertConfig.PackageDelayAlertThreshold {
+			severity := alertConfig.PackageDelaySeverity
+			if alertConfig.PackageDelayCriticalThreshold > 0 &&
+				time.Now().Unix()-claimLog.UpdateTime > alertConfig.PackageDelayCriticalThreshold {
+				severity = util.SeverityCritical
+			}
+			alertMsg := fmt.Sprintf("[%s] cross chain package was confirmed but not relayed, confiremd_time=%s, chain_id=%d, sequence=%d",
+				alertConfig.Moniker, time.Unix(claimLog.UpdateTime, 0).String(), chainId, claimLog.OracleSequence)
 
-		if time.Now().Unix()-claimLog.UpdateTime > r.Config.AlertConfig.PackageDelayAlertThreshold {
-			alertMsg := fmt.Sprintf("[%s] cross chain package was confirmed but not relayed, confiremd_time=%s, sequence=%d",
-				r.Config.AlertConfig.Moniker, time.Unix(claimLog.UpdateTime, 0).String(), claimLog.OracleSequence)
+			alert.Fire(dedupKey, severity, alertMsg)
+		} else {
+			alertMsg := fmt.Sprintf("[%s] resolved: cross chain packages are relayed again, chain_id=%d, sequence=%d",
+				alertConfig.Moniker, chainId, sequence)
 
-			util.SendTelegramMessage(alertMsg)
-			util.SendPagerDutyAlert(alertMsg, util.IncidentDedupKeyRelayError)
+			alert.Resolve(dedupKey, alertMsg)
 		}
 	}
 }
@@ -106,7 +106,7 @@ func TestRelayer_process_alreadyClaimed(t *testing.T) {
 			types.ValAddress(validatorAddr).String(): "claim",
 		},
 	}, nil)
-	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr))
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
 
 	relayer := NewRelayer(db, afcExecutor, config)
 
@@ -141,7 +141,7 @@ func TestRelayer_process_claimError(t *testing.T) {
 	afcExecutor := mock.NewMockAfcExecutor(ctrl)
 	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(1), nil)
 	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
-	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr))
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
 	afcExecutor.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return("", errors.New("claim error"))
 
 	relayer := NewRelayer(db, afcExecutor, config)
@@ -177,7 +177,7 @@ func TestRelayer_process_claimSuccess(t *testing.T) {
 	afcExecutor := mock.NewMockAfcExecutor(ctrl)
 	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(1), nil)
 	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
-	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr))
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
 	afcExecutor.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return("tx_hash", nil)
 
 	relayer := NewRelayer(db, afcExecutor, config)
@@ -203,3 +203,129 @@ func TestRelayer_process_claimSuccess(t *testing.T) {
 	require.Equal(t, newPackage.TxHash, "tx_hash")
 	require.Equal(t, newPackage.Status, model.PackageStatusClaimed)
 }
+
+func TestRelayer_GetOldestConfirmedPackage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	relayer := NewRelayer(db, afcExecutor, config)
+
+	claimLog, err := relayer.GetOldestConfirmedPackage(96, 1)
+	require.Nil(t, err, "error should be nil")
+	require.Nil(t, claimLog, "package should be nil")
+
+	for _, seq := range []uint64{3, 2} {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  seq,
+			PackageSequence: seq,
+			ChannelId:       2,
+			Height:          2,
+			Status:          model.PackageStatusConfirmed,
+			TxHash:          "tx_hash",
+		})
+	}
+
+	claimLog, err = relayer.GetOldestConfirmedPackage(96, 1)
+	require.Nil(t, err, "error should be nil")
+	require.NotNil(t, claimLog, "package should not be nil")
+	require.Equal(t, uint64(2), claimLog.OracleSequence)
+}
+
+func TestRelayer_process_pipeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.AFCBroadcastMode = util.BroadcastModeSync
+	config.ChainConfig.RelayWindow = 4
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(1), nil)
+	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), uint64(1), gomock.Any()).Times(1).Return("claim_tx_hash_1", nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), uint64(2), gomock.Any()).Times(1).Return("", errors.New("claim error"))
+
+	relayer := NewRelayer(db, afcExecutor, config)
+	for _, seq := range []uint64{1, 2, 3} {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  seq,
+			PackageSequence: seq,
+			ChannelId:       2,
+			Height:          2,
+			Status:          model.PackageStatusConfirmed,
+			TxHash:          "tx_hash",
+		})
+	}
+
+	// the pipeline stops at the failed claim of sequence 2
+	err = relayer.process(96)
+	require.NotNil(t, err, "error should not be nil")
+	require.Contains(t, err.Error(), "claim error")
+
+	expected := map[uint64]model.PackageStatus{
+		1: model.PackageStatusClaimed,
+		2: model.PackageStatusConfirmed,
+		3: model.PackageStatusConfirmed,
+	}
+	for seq, status := range expected {
+		pack := &model.CrossChainPackageLog{}
+		require.Nil(t, db.Where("oracle_sequence = ?", seq).First(pack).Error)
+		require.Equal(t, status, pack.Status)
+	}
+
+	// the pending claim of sequence 1 is skipped, and the following sequences are claimed
+	afcExecutor.EXPECT().Claim(gomock.Any(), uint64(2), gomock.Any()).Times(1).Return("claim_tx_hash_2", nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), uint64(3), gomock.Any()).Times(1).Return("claim_tx_hash_3", nil)
+	err = relayer.process(96)
+	require.Nil(t, err, "error should be nil")
+
+	var count int64
+	require.Nil(t, db.Model(model.ClaimTxLog{}).Count(&count).Error)
+	require.Equal(t, int64(3), count)
+}
+
+func TestRelayer_process_pipelineUnconfirmed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.AFCBroadcastMode = util.BroadcastModeSync
+	config.ChainConfig.RelayWindow = 4
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(1), nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	relayer := NewRelayer(db, afcExecutor, config)
+	for seq, status := range map[uint64]model.PackageStatus{1: model.PackageStatusInit, 2: model.PackageStatusConfirmed} {
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  seq,
+			PackageSequence: seq,
+			ChannelId:       2,
+			Height:          2,
+			Status:          status,
+			TxHash:          "tx_hash",
+		})
+	}
+
+	// sequence 2 is not claimed before sequence 1 is confirmed
+	err = relayer.process(96)
+	require.NotNil(t, err, "error should not be nil")
+	require.Contains(t, err.Error(), "no packages found")
+}
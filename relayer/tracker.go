@@ -0,0 +1,98 @@
+package relayer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// TrackClaimTxs tracks the pending claim txs broadcast in sync mode every ClaimTxTrackInterval
+func (r *Relayer) TrackClaimTxs() {
+	for {
+		if err := r.trackClaimTxs(); err != nil {
+			util.Logger.Errorf("track claim txs error, err=%s", err.Error())
+		}
+		time.Sleep(common.ClaimTxTrackInterval)
+	}
+}
+
+// trackClaimTxs checks whether the pending claim txs are included in blocks. A claim tx is confirmed if it succeeds,
+// and failed if it fails or is not included in ClaimTxDropTimeout seconds, the packages of failed claim txs are
+// put back to be claimed again.
+func (r *Relayer) trackClaimTxs() error {
+	claimTxs := make([]*model.ClaimTxLog, 0)
+	err := r.DB.Where("status = ?", model.ClaimTxStatusPending).Order("id asc").Find(&claimTxs).Error
+	if err != nil {
+		return fmt.Errorf("query pending claim txs error, err=%s", err.Error())
+	}
+
+	for _, claimTx := range claimTxs {
+		result, err := r.AFCExecutor.GetTx(claimTx.TxHash)
+		if err != nil {
+			util.Logger.Errorf("get claim tx error, tx_hash=%s, err=%s", claimTx.TxHash, err.Error())
+			continue
+		}
+
+		switch {
+		case result == nil && time.Now().Unix()-claimTx.CreateTime > common.ClaimTxDropTimeout:
+			// the account sequence of the dropped tx is not consumed, so the following claims would be stuck
+			r.AFCExecutor.ResetAccountSequence()
+			err = r.failClaimTx(claimTx, 0, fmt.Sprintf("not included in %d seconds", common.ClaimTxDropTimeout))
+		case result == nil:
+			continue
+		case result.Code != 0:
+			err = r.failClaimTx(claimTx, result.Height, fmt.Sprintf("code=%d, log=%s", result.Code, result.Log))
+		default:
+			err = r.DB.Model(claimTx).Update(map[string]interface{}{
+				"status":      model.ClaimTxStatusConfirmed,
+				"height":      result.Height,
+				"update_time": time.Now().Unix(),
+			}).Error
+			if err == nil {
+				util.Logger.Infof("claim tx confirmed, chain_id=%d, seq=%d, tx_hash=%s, height=%d",
+					claimTx.ChainId, claimTx.OracleSequence, claimTx.TxHash, result.Height)
+			}
+		}
+		if err != nil {
+			util.Logger.Errorf("update claim tx error, tx_hash=%s, err=%s", claimTx.TxHash, err.Error())
+		}
+	}
+	return nil
+}
+
+// failClaimTx marks the claim tx as failed and puts its packages back to confirmed in one transaction
+func (r *Relayer) failClaimTx(claimTx *model.ClaimTxLog, height int64, errMsg string) error {
+	util.Logger.Errorf("claim tx failed, chain_id=%d, seq=%d, tx_hash=%s, err=%s",
+		claimTx.ChainId, claimTx.OracleSequence, claimTx.TxHash, errMsg)
+
+	tx := r.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	err := tx.Model(claimTx).Update(map[string]interface{}{
+		"status":      model.ClaimTxStatusFailed,
+		"height":      height,
+		"error_msg":   errMsg,
+		"update_time": time.Now().Unix(),
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// update_time is kept, so that the packages are still alerted as delayed since they were confirmed
+	err = tx.Model(model.CrossChainPackageLog{}).Where("claim_tx_hash = ? and status = ?",
+		claimTx.TxHash, model.PackageStatusClaimed).UpdateColumns(map[string]interface{}{
+		"status":        model.PackageStatusConfirmed,
+		"claim_tx_hash": "",
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
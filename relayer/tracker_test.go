@@ -0,0 +1,120 @@
+package relayer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/mock"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestRelayer_process_claimSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	config.ChainConfig.AFCBroadcastMode = util.BroadcastModeSync
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetCurrentSequence(gomock.Any()).AnyTimes().Return(int64(1), nil)
+	afcExecutor.EXPECT().GetProphecy(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+	afcExecutor.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return("claim_tx_hash", nil)
+
+	relayer := NewRelayer(db, afcExecutor, config)
+	db.Create(&model.CrossChainPackageLog{
+		ChainId:         96,
+		OracleSequence:  1,
+		PackageSequence: 1,
+		ChannelId:       2,
+		Height:          2,
+		Status:          model.PackageStatusConfirmed,
+		TxHash:          "tx_hash",
+	})
+
+	err = relayer.process(96)
+	require.Nil(t, err, "error should be nil")
+
+	claimTx := &model.ClaimTxLog{}
+	err = db.Where("tx_hash = ?", "claim_tx_hash").First(claimTx).Error
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, model.ClaimTxStatusPending, claimTx.Status)
+	require.Equal(t, uint64(1), claimTx.OracleSequence)
+}
+
+func TestRelayer_trackClaimTxs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetTx("confirmed").Return(&common.TxResult{Height: 10}, nil)
+	afcExecutor.EXPECT().GetTx("rejected").Return(&common.TxResult{Height: 11, Code: 1, Log: "invalid"}, nil)
+	afcExecutor.EXPECT().GetTx("dropped").Return(nil, nil)
+	afcExecutor.EXPECT().GetTx("pending").Return(nil, nil)
+	afcExecutor.EXPECT().GetTx("unknown").Return(nil, errors.New("provider down"))
+	afcExecutor.EXPECT().ResetAccountSequence().Times(1)
+
+	relayer := NewRelayer(db, afcExecutor, config)
+	for idx, txHash := range []string{"confirmed", "rejected", "dropped", "pending", "unknown"} {
+		db.Create(&model.ClaimTxLog{
+			ChainId:        96,
+			OracleSequence: uint64(idx),
+			TxHash:         txHash,
+			Status:         model.ClaimTxStatusPending,
+		})
+		db.Create(&model.CrossChainPackageLog{
+			ChainId:         96,
+			OracleSequence:  uint64(idx),
+			PackageSequence: uint64(idx),
+			ChannelId:       2,
+			Height:          2,
+			Status:          model.PackageStatusClaimed,
+			TxHash:          "tx_hash",
+			ClaimTxHash:     txHash,
+		})
+	}
+	dropTime := time.Now().Unix() - common.ClaimTxDropTimeout - 1
+	db.Model(model.ClaimTxLog{}).Where("tx_hash = ?", "dropped").UpdateColumn("create_time", dropTime)
+
+	err = relayer.trackClaimTxs()
+	require.Nil(t, err, "error should be nil")
+
+	expected := map[string]struct {
+		txStatus      model.ClaimTxStatus
+		packageStatus model.PackageStatus
+	}{
+		"confirmed": {model.ClaimTxStatusConfirmed, model.PackageStatusClaimed},
+		"rejected":  {model.ClaimTxStatusFailed, model.PackageStatusConfirmed},
+		"dropped":   {model.ClaimTxStatusFailed, model.PackageStatusConfirmed},
+		"pending":   {model.ClaimTxStatusPending, model.PackageStatusClaimed},
+		"unknown":   {model.ClaimTxStatusPending, model.PackageStatusClaimed},
+	}
+	for idx, txHash := range []string{"confirmed", "rejected", "dropped", "pending", "unknown"} {
+		claimTx := &model.ClaimTxLog{}
+		require.Nil(t, db.Where("tx_hash = ?", txHash).First(claimTx).Error)
+		require.Equal(t, expected[txHash].txStatus, claimTx.Status, txHash)
+
+		pack := &model.CrossChainPackageLog{}
+		require.Nil(t, db.Where("oracle_sequence = ?", idx).First(pack).Error)
+		require.Equal(t, expected[txHash].packageStatus, pack.Status, txHash)
+		if pack.Status == model.PackageStatusConfirmed {
+			require.Equal(t, "", pack.ClaimTxHash)
+		}
+	}
+}
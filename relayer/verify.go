@@ -0,0 +1,211 @@
+package relayer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aximchain/go-sdk/types/msg"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// verifyClaims compares the payload to claim with the claims of the other validators in the prophecy, and fires
+// alert of the oracle sequence with the differing packages if any claim mismatches. The alert is resolved once
+// there are claims of the other validators and all of them match, or once the oracle sequence is accepted. The
+// differing claims are returned.
+func (r *Relayer) verifyClaims(chainId uint16, sequence int64, prophecy *msg.Prophecy, validatorAddress string,
+	payload []byte) []claimDiff {
+	if prophecy == nil {
+		return nil
+	}
+
+	diffs := diffClaims(prophecy, validatorAddress, payload)
+	if len(diffs) == 0 {
+		// nothing is compared if no other validator has claimed yet
+		others := len(prophecy.ValidatorClaims)
+		if _, ok := prophecy.ValidatorClaims[validatorAddress]; ok {
+			others--
+		}
+		if others > 0 {
+			r.resolveMismatch(chainId, sequence, "claim payloads match")
+		}
+		return diffs
+	}
+
+	descs := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		util.Logger.Errorf("claim payload mismatch, chain_id=%d, seq=%d, validator=%s, diff=%s",
+			chainId, sequence, diff.validator, diff.desc)
+		descs = append(descs, fmt.Sprintf("validator=%s, diff=%s", diff.validator, diff.desc))
+	}
+	alertMsg := fmt.Sprintf("[%s] claim payload mismatch, the packages in database may be wrong, chain_id=%d, seq=%d, %s",
+		r.Config.Alert().Moniker, chainId, sequence, strings.Join(descs, "; "))
+	alert.Fire(mismatchDedupKey(chainId, sequence), util.SeverityCritical, alertMsg)
+
+	r.mismatchMtx.Lock()
+	if r.mismatches[chainId] == nil {
+		r.mismatches[chainId] = make(map[int64]bool)
+	}
+	r.mismatches[chainId][sequence] = true
+	r.mismatchMtx.Unlock()
+	return diffs
+}
+
+// resolveAcceptedMismatches resolves the mismatch alerts of the oracle sequences below the given current one, since
+// they are accepted by Axim Chain already
+func (r *Relayer) resolveAcceptedMismatches(chainId uint16, current int64) {
+	r.mismatchMtx.Lock()
+	sequences := make([]int64, 0)
+	for sequence := range r.mismatches[chainId] {
+		if sequence < current {
+			sequences = append(sequences, sequence)
+		}
+	}
+	r.mismatchMtx.Unlock()
+
+	for _, sequence := range sequences {
+		r.resolveMismatch(chainId, sequence, "oracle sequence is accepted")
+	}
+}
+
+func (r *Relayer) resolveMismatch(chainId uint16, sequence int64, reason string) {
+	r.mismatchMtx.Lock()
+	fired := r.mismatches[chainId][sequence]
+	delete(r.mismatches[chainId], sequence)
+	r.mismatchMtx.Unlock()
+	if !fired {
+		return
+	}
+
+	alertMsg := fmt.Sprintf("[%s] resolved: %s, chain_id=%d, seq=%d",
+		r.Config.Alert().Moniker, reason, chainId, sequence)
+	alert.Resolve(mismatchDedupKey(chainId, sequence), alertMsg)
+}
+
+func mismatchDedupKey(chainId uint16, sequence int64) string {
+	return fmt.Sprintf("%s_%d", alert.ChainDedupKey(alert.IncidentDedupKeyPayloadMismatch, chainId), sequence)
+}
+
+// verifyPendingClaim verifies our claim of the given pending oracle sequence again, since the other validators may
+// claim it after us
+func (r *Relayer) verifyPendingClaim(chainId uint16, sequence int64) ([]claimDiff, error) {
+	claimLogs := make([]*model.CrossChainPackageLog, 0)
+	err := r.DB.Where("oracle_sequence = ? and chain_id = ? and status = ?",
+		sequence, chainId, model.PackageStatusClaimed).Order("height asc, tx_index asc").Find(&claimLogs).Error
+	if err != nil {
+		return nil, fmt.Errorf("query claimed log error, err=%s", err.Error())
+	}
+	if len(claimLogs) == 0 {
+		return nil, nil
+	}
+
+	prophecy, err := r.AFCExecutor.GetProphecy(chainId, sequence)
+	if err != nil {
+		return nil, fmt.Errorf("get prophecy error, err=%s", err.Error())
+	}
+	validatorAddress, err := r.AFCExecutor.GetAddress()
+	if err != nil {
+		return nil, fmt.Errorf("get validator address error, err=%s", err.Error())
+	}
+	payload, err := EncodePackages(claimLogs)
+	if err != nil {
+		return nil, err
+	}
+	return r.verifyClaims(chainId, sequence, prophecy, validatorAddress.String(), payload), nil
+}
+
+type claimDiff struct {
+	validator string
+	desc      string
+}
+
+// diffClaims returns the claims of the other validators which differ from the payload, in the order of validators.
+// A claim is the hex of the payload claimed by the validator.
+func diffClaims(prophecy *msg.Prophecy, validatorAddress string, payload []byte) []claimDiff {
+	validators := make([]string, 0, len(prophecy.ValidatorClaims))
+	for validator := range prophecy.ValidatorClaims {
+		if validator != validatorAddress {
+			validators = append(validators, validator)
+		}
+	}
+	sort.Strings(validators)
+
+	diffs := make([]claimDiff, 0)
+	for _, validator := range validators {
+		claim := prophecy.ValidatorClaims[validator]
+		theirPayload, err := hex.DecodeString(claim)
+		if err != nil {
+			diffs = append(diffs, claimDiff{validator, fmt.Sprintf("invalid claim, claim=%s", claim)})
+			continue
+		}
+		if bytes.Equal(theirPayload, payload) {
+			continue
+		}
+		diffs = append(diffs, claimDiff{validator, diffPayloads(payload, theirPayload)})
+	}
+	return diffs
+}
+
+// diffPayloads describes the packages which differ between the given payloads by the hashes of the package payloads
+func diffPayloads(ourPayload, theirPayload []byte) string {
+	var ours, theirs msg.Packages
+	if err := rlp.DecodeBytes(ourPayload, &ours); err != nil {
+		return fmt.Sprintf("invalid payload, hash=%s", payloadHash(ourPayload))
+	}
+	if err := rlp.DecodeBytes(theirPayload, &theirs); err != nil {
+		return fmt.Sprintf("invalid claim payload, hash=%s", payloadHash(theirPayload))
+	}
+
+	type packageKey struct {
+		channelId uint8
+		sequence  uint64
+	}
+	ourHashes := make(map[packageKey]string)
+	theirHashes := make(map[packageKey]string)
+	keys := make([]packageKey, 0)
+	for _, pack := range ours {
+		key := packageKey{uint8(pack.ChannelId), pack.Sequence}
+		ourHashes[key] = payloadHash(pack.Payload)
+		keys = append(keys, key)
+	}
+	for _, pack := range theirs {
+		key := packageKey{uint8(pack.ChannelId), pack.Sequence}
+		theirHashes[key] = payloadHash(pack.Payload)
+		if _, ok := ourHashes[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	descs := make([]string, 0)
+	for _, key := range keys {
+		ourHash, theirHash := ourHashes[key], theirHashes[key]
+		if ourHash == theirHash {
+			continue
+		}
+		descs = append(descs, fmt.Sprintf("channel_id=%d, package_sequence=%d, ours=%s, theirs=%s",
+			key.channelId, key.sequence, hashOrMissing(ourHash), hashOrMissing(theirHash)))
+	}
+	if len(descs) == 0 {
+		return "packages in different order"
+	}
+	return strings.Join(descs, "; ")
+}
+
+func payloadHash(payload []byte) string {
+	hash := sha256.Sum256(payload)
+	return hex.EncodeToString(hash[:])
+}
+
+func hashOrMissing(hash string) string {
+	if hash == "" {
+		return "missing"
+	}
+	return hash
+}
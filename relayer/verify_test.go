@@ -0,0 +1,102 @@
+package relayer
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/aximchain/go-sdk/common/types"
+	"github.com/aximchain/go-sdk/types/msg"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/mock"
+	"github.com/Sotatek-huytran2/oracle-relayer/model"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+func TestDiffClaims(t *testing.T) {
+	encode := func(packages ...*model.CrossChainPackageLog) []byte {
+		payload, err := EncodePackages(packages)
+		require.Nil(t, err)
+		return payload
+	}
+	pack1 := &model.CrossChainPackageLog{ChannelId: 2, PackageSequence: 1, PayLoad: "01"}
+	pack2 := &model.CrossChainPackageLog{ChannelId: 2, PackageSequence: 2, PayLoad: "02"}
+	forkedPack2 := &model.CrossChainPackageLog{ChannelId: 2, PackageSequence: 2, PayLoad: "03"}
+	pack3 := &model.CrossChainPackageLog{ChannelId: 3, PackageSequence: 1, PayLoad: "04"}
+
+	payload := encode(pack1, pack2)
+	prophecy := &msg.Prophecy{
+		ValidatorClaims: map[string]string{
+			"ours":      "invalid",
+			"same":      hex.EncodeToString(payload),
+			"forked":    hex.EncodeToString(encode(pack1, forkedPack2)),
+			"extra":     hex.EncodeToString(encode(pack1, pack2, pack3)),
+			"missing":   hex.EncodeToString(encode(pack1)),
+			"reordered": hex.EncodeToString(encode(pack2, pack1)),
+			"invalid":   "xyz",
+		},
+	}
+
+	diffs := diffClaims(prophecy, "ours", payload)
+	require.Equal(t, []claimDiff{
+		{"extra", "channel_id=3, package_sequence=1, ours=missing, theirs=" + payloadHash([]byte{0x04})},
+		{"forked", "channel_id=2, package_sequence=2, ours=" + payloadHash([]byte{0x02}) + ", theirs=" + payloadHash([]byte{0x03})},
+		{"invalid", "invalid claim, claim=xyz"},
+		{"missing", "channel_id=2, package_sequence=2, ours=" + payloadHash([]byte{0x02}) + ", theirs=missing"},
+		{"reordered", "packages in different order"},
+	}, diffs)
+
+	relayer := NewRelayer(nil, nil, util.GetTestConfig())
+	require.Equal(t, diffs, relayer.verifyClaims(96, 1, prophecy, "ours", payload))
+	require.Empty(t, relayer.verifyClaims(96, 1, nil, "ours", payload))
+	require.True(t, relayer.mismatches[96][1])
+
+	// the alert of sequence 1 is not resolved by the next sequence which no other validator has claimed
+	onlyOurs := &msg.Prophecy{ValidatorClaims: map[string]string{"ours": hex.EncodeToString(payload)}}
+	require.Empty(t, relayer.verifyClaims(96, 2, onlyOurs, "ours", payload))
+	require.True(t, relayer.mismatches[96][1])
+
+	// it is resolved once sequence 1 is accepted
+	relayer.resolveAcceptedMismatches(96, 2)
+	require.False(t, relayer.mismatches[96][1])
+}
+
+func TestRelayer_verifyPendingClaim(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := util.GetTestConfig()
+	db, err := util.PrepareDB(config)
+	require.Nil(t, err, "create db error")
+
+	validatorAddr, err := types.AccAddressFromBech32("axc1w7puzjxu05ktc5zvpnzkndt6tyl720nsutzvpg")
+	require.Nil(t, err, "error should be nil")
+	ourAddress := types.ValAddress(validatorAddr).String()
+
+	pack := &model.CrossChainPackageLog{ChainId: 96, OracleSequence: 1, PackageSequence: 1, ChannelId: 2, Height: 2,
+		PayLoad: "01", Status: model.PackageStatusClaimed, TxHash: "tx_hash"}
+	require.Nil(t, db.Create(pack).Error)
+	payload, err := EncodePackages([]*model.CrossChainPackageLog{pack})
+	require.Nil(t, err)
+
+	// the other validator claims a different payload after our claim
+	afcExecutor := mock.NewMockAfcExecutor(ctrl)
+	afcExecutor.EXPECT().GetAddress().AnyTimes().Return(types.ValAddress(validatorAddr), nil)
+	afcExecutor.EXPECT().GetProphecy(uint16(96), int64(1)).Times(1).Return(&msg.Prophecy{
+		ValidatorClaims: map[string]string{
+			ourAddress: hex.EncodeToString(payload),
+			"other":    "xyz",
+		},
+	}, nil)
+
+	relayer := NewRelayer(db, afcExecutor, config)
+	diffs, err := relayer.verifyPendingClaim(96, 1)
+	require.Nil(t, err, "error should be nil")
+	require.Equal(t, []claimDiff{{"other", "invalid claim, claim=xyz"}}, diffs)
+
+	// nothing is verified if the oracle sequence is not claimed by us
+	diffs, err = relayer.verifyPendingClaim(96, 2)
+	require.Nil(t, err, "error should be nil")
+	require.Empty(t, diffs)
+}
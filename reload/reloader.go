@@ -0,0 +1,111 @@
+package reload
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/Sotatek-huytran2/oracle-relayer/alert"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/afc"
+	"github.com/Sotatek-huytran2/oracle-relayer/executor/asc"
+	"github.com/Sotatek-huytran2/oracle-relayer/util"
+)
+
+// Loader loads and validates the config from the same source the relayer is started with
+type Loader func() (*util.Config, error)
+
+// Reloader reloads the config fields which can be changed without restart, eg(providers, relay interval, alert
+// config and log level), and applies them to the running executors. The observers and the relayer read the
+// reloadable fields by the accessors of the config, so they pick up the changes on their next loop.
+type Reloader struct {
+	mtx sync.Mutex
+
+	Config       *util.Config
+	AfcExecutor  *afc.Executor
+	AscExecutors []*asc.Executor
+	loader       Loader
+}
+
+// NewReloader returns the reloader, ascExecutors are those of every source chain in the order of the chain configs
+func NewReloader(config *util.Config, loader Loader, afcExecutor *afc.Executor, ascExecutors []*asc.Executor) *Reloader {
+	return &Reloader{
+		Config:       config,
+		AfcExecutor:  afcExecutor,
+		AscExecutors: ascExecutors,
+		loader:       loader,
+	}
+}
+
+// Reload loads the config and applies the changed fields, it returns the paths of the changed fields. Nothing is
+// applied if any field which can not be changed without restart is changed.
+func (r *Reloader) Reload() ([]string, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	newCfg, err := r.loader()
+	if err != nil {
+		return nil, fmt.Errorf("load config error, err=%s", err.Error())
+	}
+	changed, err := r.Config.Changes(newCfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	// the providers are replaced before the config, so that the config is not changed if dialing fails
+	for idx, chainConfig := range newCfg.ChainConfigs {
+		if isChanged(changed, fmt.Sprintf("chain_config[%d].asc_providers", idx)) {
+			if err := r.AscExecutors[idx].SetProviders(chainConfig.ASCProviders); err != nil {
+				return nil, fmt.Errorf("set asc providers error, chain_id=%d, err=%s", chainConfig.ASCChainId, err.Error())
+			}
+		}
+	}
+	if isChanged(changed, "chain_config[0].afc_rpc_addrs") {
+		r.AfcExecutor.SetProviders(newCfg.ChainConfig.AFCRpcAddrs)
+	}
+
+	changed, err = r.Config.Reload(newCfg)
+	if err != nil {
+		return nil, err
+	}
+	if isChanged(changed, "log_config.level") {
+		util.SetLogLevel(r.Config.LogLevel())
+	}
+	for _, path := range changed {
+		if strings.HasPrefix(path, "alert_config.") {
+			if err := alert.Init(r.Config.Alert()); err != nil {
+				util.Logger.Errorf("init alerters error, err=%s", err.Error())
+			}
+			break
+		}
+	}
+
+	util.Logger.Infof("config reloaded, changed=%s", strings.Join(changed, ","))
+	return changed, nil
+}
+
+// HandleSignals reloads the config on every SIGHUP
+func (r *Reloader) HandleSignals() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	for range sigCh {
+		if _, err := r.Reload(); err != nil {
+			util.Logger.Errorf("reload config error, err=%s", err.Error())
+		}
+	}
+}
+
+func isChanged(changed []string, path string) bool {
+	for _, p := range changed {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
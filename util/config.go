@@ -1,18 +1,51 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
 
+	"github.com/BurntSushi/toml"
 	ethcmm "github.com/ethereum/go-ethereum/common"
+	"sigs.k8s.io/yaml"
 
-	"github.com/aximchain/oracle-relayer/common"
+	"github.com/Sotatek-huytran2/oracle-relayer/common"
 )
 
 const (
-	KeyTypeMnemonic    = "mnemonic"
-	KeyTypeAWSMnemonic = "aws_mnemonic"
+	KeyTypeMnemonic     = "mnemonic"
+	KeyTypeAWSMnemonic  = "aws_mnemonic"
+	KeyTypeKeystore     = "keystore"
+	KeyTypeRemoteSigner = "remote_signer"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityError    = "error"
+	SeverityCritical = "critical"
+)
+
+const (
+	DefaultPagerDutySummary   = "[{{.Moniker}}] oracle relayer {{.Severity}} detected, dedup_key={{.DedupKey}}"
+	DefaultPagerDutyComponent = "oracle_relayer"
+	DefaultPagerDutyClass     = "oracle_relayer"
+)
+
+const (
+	BroadcastModeCommit = "commit"
+	BroadcastModeSync   = "sync"
+)
+
+const (
+	ConfirmModeDepth     = "depth"
+	ConfirmModeFinalized = "finalized"
+	ConfirmModeSafe      = "safe"
 )
 
 type Config struct {
@@ -21,13 +54,115 @@ type Config struct {
 	LogConfig   *LogConfig   `json:"log_config"`
 	AlertConfig *AlertConfig `json:"alert_config"`
 	AdminConfig *AdminConfig `json:"admin_config"`
+
+	// ChainConfigs is the config of every source chain, chain_config can be either one chain config or a list of
+	// chain configs. ChainConfig is the first of them, whose AFC settings are shared by all the chains.
+	ChainConfigs []*ChainConfig `json:"-"`
+
+	// chainConfigList is whether chain_config is a list, it decides the json paths of chain config problems
+	chainConfigList bool
+
+	// mtx guards the fields which can be reloaded, they should be read by the accessors in reload.go once the
+	// relayer is started
+	mtx sync.RWMutex
 }
 
-func (cfg *Config) Validate() {
-	cfg.DBConfig.Validate()
-	cfg.ChainConfig.Validate()
-	cfg.LogConfig.Validate()
-	cfg.AlertConfig.Validate()
+// UnmarshalJSON parses the config with chain_config being either an object or a list
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	type plainConfig Config
+	raw := struct {
+		*plainConfig
+		ChainConfig json.RawMessage `json:"chain_config"`
+	}{
+		plainConfig: (*plainConfig)(cfg),
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	chainConfig := bytes.TrimSpace(raw.ChainConfig)
+	cfg.chainConfigList = false
+	switch {
+	case len(chainConfig) == 0 || bytes.Equal(chainConfig, []byte("null")):
+		cfg.ChainConfigs = nil
+	case chainConfig[0] == '[':
+		cfg.chainConfigList = true
+		if err := json.Unmarshal(chainConfig, &cfg.ChainConfigs); err != nil {
+			return err
+		}
+	default:
+		single := &ChainConfig{}
+		if err := json.Unmarshal(chainConfig, single); err != nil {
+			return err
+		}
+		cfg.ChainConfigs = []*ChainConfig{single}
+	}
+
+	cfg.ChainConfig = nil
+	if len(cfg.ChainConfigs) > 0 {
+		cfg.ChainConfig = cfg.ChainConfigs[0]
+	}
+	return nil
+}
+
+// Validate checks the whole config and fills the defaults, all the problems found are returned as a ValidationError
+func (cfg *Config) Validate() error {
+	v := newValidator("")
+	if cfg.DBConfig == nil {
+		v.addf("db_config", "should not be empty")
+	} else {
+		cfg.DBConfig.validate(v.sub("db_config"))
+	}
+	cfg.validateChainConfigs(v)
+	if cfg.LogConfig == nil {
+		v.addf("log_config", "should not be empty")
+	} else {
+		cfg.LogConfig.validate(v.sub("log_config"))
+	}
+	if cfg.AlertConfig == nil {
+		v.addf("alert_config", "should not be empty")
+	} else {
+		cfg.AlertConfig.validate(v.sub("alert_config"))
+	}
+	// the admin config is optional, it is set so that the auth token can be added by reload
+	if cfg.AdminConfig == nil {
+		cfg.AdminConfig = &AdminConfig{}
+	}
+	return v.err()
+}
+
+// validateChainConfigs validates every chain config. The AFC settings missing in the chain configs after the
+// first one are copied from the first one, since all the chains are relayed by the same AFC key.
+func (cfg *Config) validateChainConfigs(v *validator) {
+	if len(cfg.ChainConfigs) == 0 {
+		v.addf("chain_config", "should not be empty")
+		return
+	}
+
+	first := cfg.ChainConfigs[0]
+	chainIds := make(map[uint16]bool)
+	chainNames := make(map[string]bool)
+	for idx, chainConfig := range cfg.ChainConfigs {
+		chainValidator := v.sub("chain_config")
+		if cfg.chainConfigList {
+			chainValidator = chainValidator.sub(fmt.Sprintf("[%d]", idx))
+		}
+
+		if chainConfig != first {
+			chainConfig.inheritAFCConfig(chainValidator, first)
+		}
+		chainConfig.validate(chainValidator)
+
+		if chainIds[chainConfig.ASCChainId] {
+			chainValidator.addf("asc_chain_id", "%d is duplicated", chainConfig.ASCChainId)
+		}
+		chainIds[chainConfig.ASCChainId] = true
+
+		if chainNames[chainConfig.ASCChainName] {
+			chainValidator.addf("asc_chain_name", "%q is duplicated", chainConfig.ASCChainName)
+		}
+		chainNames[chainConfig.ASCChainName] = true
+	}
 }
 
 type AlertConfig struct {
@@ -37,45 +172,146 @@ type AlertConfig struct {
 	TelegramChatId string `json:"telegram_chat_id"`
 
 	PagerDutyAuthToken string `json:"pager_duty_auth_token"`
+	// PagerDutySummary is the text/template of incident summaries, with fields Moniker, DedupKey, Severity and Message
+	PagerDutySummary   string `json:"pager_duty_summary"`
+	PagerDutySource    string `json:"pager_duty_source"`
+	PagerDutyComponent string `json:"pager_duty_component"`
+	PagerDutyGroup     string `json:"pager_duty_group"`
+	PagerDutyClass     string `json:"pager_duty_class"`
+
+	SlackWebhookUrl string `json:"slack_webhook_url"`
+
+	WebhookUrl     string            `json:"webhook_url"`
+	WebhookHeaders map[string]string `json:"webhook_headers"`
+
+	SmtpHost     string   `json:"smtp_host"`
+	SmtpPort     int      `json:"smtp_port"`
+	SmtpUsername string   `json:"smtp_username"`
+	SmtpPassword string   `json:"smtp_password"`
+	SmtpFrom     string   `json:"smtp_from"`
+	SmtpTo       []string `json:"smtp_to"`
 
 	BlockUpdateTimeOut         int64 `json:"block_update_time_out"`
 	PackageDelayAlertThreshold int64 `json:"package_delay_alert_threshold"`
+	// the severities of the alerts once the thresholds above are exceeded
+	BlockUpdateSeverity  string `json:"block_update_severity"`
+	PackageDelaySeverity string `json:"package_delay_severity"`
+	// the thresholds to escalate the alerts to critical, they are disabled if not set
+	BlockUpdateCriticalTimeOut    int64 `json:"block_update_critical_time_out"`
+	PackageDelayCriticalThreshold int64 `json:"package_delay_critical_threshold"`
+	// RepeatInterval is the interval in seconds to repeat alerts of conditions which still hold
+	RepeatInterval int64 `json:"repeat_interval"`
 }
 
-func (cfg *AlertConfig) Validate() {
+// Validate checks the alert config and fills the defaults
+func (cfg *AlertConfig) Validate() error {
+	v := newValidator("")
+	cfg.validate(v)
+	return v.err()
+}
+
+func (cfg *AlertConfig) validate(v *validator) {
 	if cfg.Moniker == "" {
-		panic("moniker should not be empty")
+		v.addf("moniker", "should not be empty")
 	}
 
 	if cfg.BlockUpdateTimeOut <= 0 {
-		panic("block_update_time_out should be larger than 0")
+		v.addf("block_update_time_out", "should be larger than 0")
 	}
 
 	if cfg.PackageDelayAlertThreshold <= 0 {
-		panic("package_delay_alert_threshold should be larger than 0")
+		v.addf("package_delay_alert_threshold", "should be larger than 0")
+	}
+
+	if cfg.BlockUpdateCriticalTimeOut != 0 && cfg.BlockUpdateCriticalTimeOut <= cfg.BlockUpdateTimeOut {
+		v.addf("block_update_critical_time_out", "should be larger than block_update_time_out")
+	}
+	if cfg.PackageDelayCriticalThreshold != 0 && cfg.PackageDelayCriticalThreshold <= cfg.PackageDelayAlertThreshold {
+		v.addf("package_delay_critical_threshold", "should be larger than package_delay_alert_threshold")
+	}
+
+	if cfg.BlockUpdateSeverity == "" {
+		cfg.BlockUpdateSeverity = SeverityError
+	}
+	if !isSeverity(cfg.BlockUpdateSeverity) {
+		v.addf("block_update_severity", "only supports %s, %s, %s and %s", SeverityInfo, SeverityWarning, SeverityError, SeverityCritical)
+	}
+	if cfg.PackageDelaySeverity == "" {
+		cfg.PackageDelaySeverity = SeverityError
+	}
+	if !isSeverity(cfg.PackageDelaySeverity) {
+		v.addf("package_delay_severity", "only supports %s, %s, %s and %s", SeverityInfo, SeverityWarning, SeverityError, SeverityCritical)
+	}
+
+	if cfg.RepeatInterval < 0 {
+		v.addf("repeat_interval", "should not be less than 0")
+	}
+
+	if cfg.PagerDutySummary == "" {
+		cfg.PagerDutySummary = DefaultPagerDutySummary
+	}
+	if _, err := template.New("summary").Parse(cfg.PagerDutySummary); err != nil {
+		v.addf("pager_duty_summary", "is not a valid template, err=%s", err.Error())
+	}
+	if cfg.PagerDutySource == "" {
+		cfg.PagerDutySource = cfg.Moniker
+	}
+	if cfg.PagerDutyComponent == "" {
+		cfg.PagerDutyComponent = DefaultPagerDutyComponent
+	}
+	if cfg.PagerDutyClass == "" {
+		cfg.PagerDutyClass = DefaultPagerDutyClass
+	}
+
+	if cfg.SmtpHost != "" {
+		if cfg.SmtpPort <= 0 {
+			v.addf("smtp_port", "should be larger than 0 if smtp_host is set")
+		}
+		if cfg.SmtpFrom == "" {
+			v.addf("smtp_from", "should not be empty if smtp_host is set")
+		}
+		if len(cfg.SmtpTo) == 0 {
+			v.addf("smtp_to", "should not be empty if smtp_host is set")
+		}
 	}
 }
 
+func isSeverity(severity string) bool {
+	return severity == SeverityInfo || severity == SeverityWarning || severity == SeverityError ||
+		severity == SeverityCritical
+}
+
 type DBConfig struct {
 	Dialect string `json:"dialect"`
 	DBPath  string `json:"db_path"`
 }
 
-func (cfg *DBConfig) Validate() {
+// Validate checks the db config
+func (cfg *DBConfig) Validate() error {
+	v := newValidator("")
+	cfg.validate(v)
+	return v.err()
+}
+
+func (cfg *DBConfig) validate(v *validator) {
 	if cfg.Dialect != common.DBDialectMysql && cfg.Dialect != common.DBDialectSqlite3 {
-		panic(fmt.Sprintf("only %s and %s supported", common.DBDialectMysql, common.DBDialectSqlite3))
+		v.addf("dialect", "only %s and %s supported", common.DBDialectMysql, common.DBDialectSqlite3)
 	}
 	if cfg.DBPath == "" {
-		panic("db path should not be empty")
+		v.addf("db_path", "should not be empty")
 	}
 }
 
 type ChainConfig struct {
+	ASCChainName                 string         `json:"asc_chain_name"`
 	ASCStartHeight               int64          `json:"asc_start_height"`
 	ASCProviders                 []string       `json:"asc_providers"`
+	ASCWsProviders               []string       `json:"asc_ws_providers"`
 	ASCConfirmNum                int64          `json:"asc_confirm_num"`
+	ASCConfirmMode               string         `json:"asc_confirm_mode"`
 	ASCChainId                   uint16         `json:"asc_chain_id"`
 	ASCCrossChainContractAddress ethcmm.Address `json:"asc_cross_chain_contract_address"`
+	ASCFetchWindow               int64          `json:"asc_fetch_window"`
 
 	AFCRpcAddrs      []string `json:"afc_rpc_addrs"`
 	AFCMnemonic      string   `json:"afc_mnemonic"`
@@ -83,48 +319,150 @@ type ChainConfig struct {
 	AFCAWSRegion     string   `json:"afc_aws_region"`
 	AFCAWSSecretName string   `json:"afc_aws_secret_name"`
 
+	AFCKeystoreFile         string `json:"afc_keystore_file"`
+	AFCKeystorePasswordFile string `json:"afc_keystore_password_file"`
+	AFCKeystorePasswordEnv  string `json:"afc_keystore_password_env"`
+
+	AFCRemoteSignerUrl       string `json:"afc_remote_signer_url"`
+	AFCRemoteSignerAuthToken string `json:"afc_remote_signer_auth_token"`
+
+	AFCBroadcastMode string `json:"afc_broadcast_mode"`
+
 	RelayInterval int64 `json:"relay_interval"`
+	// RelayWindow is the number of oracle sequences claimed at once from the current one
+	RelayWindow int64 `json:"relay_window"`
+}
+
+// inheritAFCConfig copies the AFC settings and relay interval of the given chain config if they are not set,
+// AFC settings which are set should be the same as the given ones
+func (cfg *ChainConfig) inheritAFCConfig(v *validator, first *ChainConfig) {
+	if len(cfg.AFCRpcAddrs) == 0 {
+		cfg.AFCRpcAddrs = first.AFCRpcAddrs
+	}
+	// the claims of all the chains are sent by the AFC executor of the first chain
+	if strings.Join(cfg.AFCRpcAddrs, ",") != strings.Join(first.AFCRpcAddrs, ",") {
+		v.addf("afc_rpc_addrs", "should be the same for all chains")
+	}
+	if cfg.AFCKeyType == "" {
+		cfg.AFCKeyType = first.AFCKeyType
+		cfg.AFCMnemonic = first.AFCMnemonic
+		cfg.AFCAWSRegion = first.AFCAWSRegion
+		cfg.AFCAWSSecretName = first.AFCAWSSecretName
+		cfg.AFCKeystoreFile = first.AFCKeystoreFile
+		cfg.AFCKeystorePasswordFile = first.AFCKeystorePasswordFile
+		cfg.AFCKeystorePasswordEnv = first.AFCKeystorePasswordEnv
+		cfg.AFCRemoteSignerUrl = first.AFCRemoteSignerUrl
+		cfg.AFCRemoteSignerAuthToken = first.AFCRemoteSignerAuthToken
+	}
+	if cfg.RelayInterval == 0 {
+		cfg.RelayInterval = first.RelayInterval
+	}
+	if cfg.RelayWindow == 0 {
+		cfg.RelayWindow = first.RelayWindow
+	}
+	if cfg.AFCBroadcastMode == "" {
+		cfg.AFCBroadcastMode = first.AFCBroadcastMode
+	}
+	if cfg.AFCBroadcastMode != first.AFCBroadcastMode {
+		v.addf("afc_broadcast_mode", "should be the same for all chains")
+	}
+
+	if cfg.AFCKeyType != first.AFCKeyType || cfg.AFCMnemonic != first.AFCMnemonic ||
+		cfg.AFCAWSRegion != first.AFCAWSRegion || cfg.AFCAWSSecretName != first.AFCAWSSecretName ||
+		cfg.AFCKeystoreFile != first.AFCKeystoreFile || cfg.AFCKeystorePasswordFile != first.AFCKeystorePasswordFile ||
+		cfg.AFCKeystorePasswordEnv != first.AFCKeystorePasswordEnv || cfg.AFCRemoteSignerUrl != first.AFCRemoteSignerUrl ||
+		cfg.AFCRemoteSignerAuthToken != first.AFCRemoteSignerAuthToken {
+		v.addf("afc_key_type", "afc key settings should be the same for all chains")
+	}
+}
+
+// Validate checks the chain config and fills the defaults
+func (cfg *ChainConfig) Validate() error {
+	v := newValidator("")
+	cfg.validate(v)
+	return v.err()
 }
 
-func (cfg *ChainConfig) Validate() {
+func (cfg *ChainConfig) validate(v *validator) {
 	if cfg.ASCStartHeight < 0 {
-		panic("asc_start_height should not be less than 0")
+		v.addf("asc_start_height", "should not be less than 0")
 	}
 	if len(cfg.ASCProviders) == 0 {
-		panic("asc_providers should not be empty")
+		v.addf("asc_providers", "should not be empty")
 	}
-	if cfg.ASCConfirmNum <= 0 {
-		panic("asc_confirm_num should be larger than 0")
+
+	if cfg.ASCConfirmMode == "" {
+		cfg.ASCConfirmMode = ConfirmModeDepth
+	}
+	if cfg.ASCConfirmMode != ConfirmModeDepth && cfg.ASCConfirmMode != ConfirmModeFinalized &&
+		cfg.ASCConfirmMode != ConfirmModeSafe {
+		v.addf("asc_confirm_mode", "only supports %s, %s and %s", ConfirmModeDepth, ConfirmModeFinalized, ConfirmModeSafe)
+	}
+	// asc_confirm_num is only required by depth mode, it only bounds the blocks fetched at once in other modes
+	if cfg.ASCConfirmMode == ConfirmModeDepth && cfg.ASCConfirmNum <= 0 {
+		v.addf("asc_confirm_num", "should be larger than 0")
+	}
+	if cfg.ASCConfirmNum < 0 {
+		v.addf("asc_confirm_num", "should not be less than 0")
 	}
 
-	// replace asc_confirm_num if it is less than DefaultConfirmNum
-	if cfg.ASCConfirmNum <= common.DefaultConfirmNum {
+	if cfg.ASCFetchWindow < 0 {
+		v.addf("asc_fetch_window", "should not be less than 0")
+	}
+
+	// replace asc_confirm_num if it is less than DefaultConfirmNum in depth mode
+	if cfg.ASCConfirmMode == ConfirmModeDepth && cfg.ASCConfirmNum > 0 && cfg.ASCConfirmNum <= common.DefaultConfirmNum {
 		cfg.ASCConfirmNum = common.DefaultConfirmNum
 	}
 
 	var emptyAddr ethcmm.Address
 	if cfg.ASCCrossChainContractAddress.String() == emptyAddr.String() {
-		panic("asc_token_hub_contract_address should not be empty")
+		v.addf("asc_cross_chain_contract_address", "should not be empty")
 	}
 
 	if len(cfg.AFCRpcAddrs) == 0 {
-		panic("afc_rpc_addrs should not be empty")
+		v.addf("afc_rpc_addrs", "should not be empty")
 	}
-	if cfg.AFCKeyType != KeyTypeMnemonic && cfg.AFCKeyType != KeyTypeAWSMnemonic {
-		panic(fmt.Sprintf("afc_key_type of axim chain only supports %s and %s", KeyTypeMnemonic, KeyTypeAWSMnemonic))
+	if cfg.AFCKeyType != KeyTypeMnemonic && cfg.AFCKeyType != KeyTypeAWSMnemonic && cfg.AFCKeyType != KeyTypeKeystore &&
+		cfg.AFCKeyType != KeyTypeRemoteSigner {
+		v.addf("afc_key_type", "only supports %s, %s, %s and %s", KeyTypeMnemonic, KeyTypeAWSMnemonic, KeyTypeKeystore,
+			KeyTypeRemoteSigner)
 	}
 	if cfg.AFCKeyType == KeyTypeAWSMnemonic && cfg.AFCAWSRegion == "" {
-		panic("afc_aws_region of axim chain should not be empty")
+		v.addf("afc_aws_region", "should not be empty if afc_key_type is %s", KeyTypeAWSMnemonic)
 	}
 	if cfg.AFCKeyType == KeyTypeAWSMnemonic && cfg.AFCAWSSecretName == "" {
-		panic("afc_aws_secret_name of axim chain should not be empty")
+		v.addf("afc_aws_secret_name", "should not be empty if afc_key_type is %s", KeyTypeAWSMnemonic)
 	}
 	if cfg.AFCKeyType == KeyTypeMnemonic && cfg.AFCMnemonic == "" {
-		panic("afc_mnemonic should not be empty")
+		v.addf("afc_mnemonic", "should not be empty if afc_key_type is %s", KeyTypeMnemonic)
+	}
+	if cfg.AFCKeyType == KeyTypeKeystore && cfg.AFCKeystoreFile == "" {
+		v.addf("afc_keystore_file", "should not be empty if afc_key_type is %s", KeyTypeKeystore)
+	}
+	if cfg.AFCKeyType == KeyTypeKeystore && (cfg.AFCKeystorePasswordFile == "") == (cfg.AFCKeystorePasswordEnv == "") {
+		v.addf("afc_keystore_password_file", "one of afc_keystore_password_file and afc_keystore_password_env "+
+			"should be set if afc_key_type is %s", KeyTypeKeystore)
+	}
+	if cfg.AFCKeyType == KeyTypeRemoteSigner && cfg.AFCRemoteSignerUrl == "" {
+		v.addf("afc_remote_signer_url", "should not be empty if afc_key_type is %s", KeyTypeRemoteSigner)
+	}
+
+	if cfg.AFCBroadcastMode == "" {
+		cfg.AFCBroadcastMode = BroadcastModeCommit
+	}
+	if cfg.AFCBroadcastMode != BroadcastModeCommit && cfg.AFCBroadcastMode != BroadcastModeSync {
+		v.addf("afc_broadcast_mode", "only supports %s and %s", BroadcastModeCommit, BroadcastModeSync)
 	}
 
 	if cfg.RelayInterval <= 0 {
-		panic(fmt.Sprintf("relay interval should be larger than 0"))
+		v.addf("relay_interval", "should be larger than 0")
+	}
+	if cfg.RelayWindow < 0 {
+		v.addf("relay_window", "should not be less than 0")
+	}
+	if cfg.RelayWindow > 1 && cfg.AFCBroadcastMode != BroadcastModeSync {
+		v.addf("relay_window", "should not be larger than 1 unless afc_broadcast_mode is %s", BroadcastModeSync)
 	}
 }
 
@@ -139,43 +477,98 @@ type LogConfig struct {
 	Compress                     bool   `json:"compress"`
 }
 
-func (cfg *LogConfig) Validate() {
+// Validate checks the log config
+func (cfg *LogConfig) Validate() error {
+	v := newValidator("")
+	cfg.validate(v)
+	return v.err()
+}
+
+func (cfg *LogConfig) validate(v *validator) {
+	if _, ok := levels[cfg.Level]; cfg.Level != "" && !ok {
+		v.addf("level", "only supports CRITICAL, ERROR, WARNING, NOTICE, INFO and DEBUG")
+	}
 	if cfg.UseFileLogger {
 		if cfg.Filename == "" {
-			panic("filename should not be empty if use file logger")
+			v.addf("filename", "should not be empty if use file logger")
 		}
 		if cfg.MaxFileSizeInMB <= 0 {
-			panic("max_file_size_in_mb should be larger than 0 if use file logger")
+			v.addf("max_file_size_in_mb", "should be larger than 0 if use file logger")
 		}
 		if cfg.MaxBackupsOfLogFiles <= 0 {
-			panic("max_backups_off_log_files should be larger than 0 if use file logger")
+			v.addf("max_backups_of_log_files", "should be larger than 0 if use file logger")
 		}
 	}
 }
 
 type AdminConfig struct {
 	ListenAddr string `json:"listen_addr"`
+	AuthToken  string `json:"auth_token"`
+}
+
+const (
+	ConfigFormatJson = "json"
+	ConfigFormatYaml = "yaml"
+	ConfigFormatToml = "toml"
+)
+
+// ConfigFormatOfPath returns the config format by the extension of the file path, json by default
+func ConfigFormatOfPath(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYaml
+	case ".toml":
+		return ConfigFormatToml
+	default:
+		return ConfigFormatJson
+	}
 }
 
-// ParseConfigFromFile returns the config from json file
-func ParseConfigFromFile(filePath string) *Config {
+// ParseConfigFromFile returns the config from file of the given format, the format is decided by the file
+// extension if it is empty
+func ParseConfigFromFile(filePath string, format string) (*Config, error) {
 	bz, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("read config file error, err=%s", err.Error())
+	}
+	if format == "" {
+		format = ConfigFormatOfPath(filePath)
 	}
+	return ParseConfig(bz, format)
+}
 
-	var config Config
-	if err := json.Unmarshal(bz, &config); err != nil {
-		panic(err)
+// ParseConfig returns the config from content of the given format. Yaml and toml are converted to json first, so
+// they have the same structure as json.
+func ParseConfig(content []byte, format string) (*Config, error) {
+	switch format {
+	case ConfigFormatJson:
+		return ParseConfigFromJson(string(content))
+	case ConfigFormatYaml:
+		jsonBytes, err := yaml.YAMLToJSON(content)
+		if err != nil {
+			return nil, fmt.Errorf("parse yaml config error, err=%s", err.Error())
+		}
+		return ParseConfigFromJson(string(jsonBytes))
+	case ConfigFormatToml:
+		var raw map[string]interface{}
+		if _, err := toml.Decode(string(content), &raw); err != nil {
+			return nil, fmt.Errorf("parse toml config error, err=%s", err.Error())
+		}
+		jsonBytes, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("convert toml config error, err=%s", err.Error())
+		}
+		return ParseConfigFromJson(string(jsonBytes))
+	default:
+		return nil, fmt.Errorf("config format only supports %s, %s and %s", ConfigFormatJson, ConfigFormatYaml, ConfigFormatToml)
 	}
-	return &config
 }
 
 // ParseConfigFromJson returns the config from json string
-func ParseConfigFromJson(content string) *Config {
+func ParseConfigFromJson(content string) (*Config, error) {
 	var config Config
 	if err := json.Unmarshal([]byte(content), &config); err != nil {
-		panic(err)
+		return nil, fmt.Errorf("parse config error, err=%s", err.Error())
 	}
-	return &config
+	return &config, nil
 }
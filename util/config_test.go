@@ -35,14 +35,71 @@ func TestAlertConfig(t *testing.T) {
 				PackageDelayAlertThreshold: 10,
 			},
 			false,
+		}, {
+			&AlertConfig{
+				Moniker:                    "test",
+				BlockUpdateTimeOut:         10,
+				PackageDelayAlertThreshold: 10,
+				SmtpHost:                   "smtp",
+				SmtpPort:                   587,
+				SmtpFrom:                   "relayer@example.com",
+			},
+			true,
+		}, {
+			&AlertConfig{
+				Moniker:                    "test",
+				BlockUpdateTimeOut:         10,
+				PackageDelayAlertThreshold: 10,
+				SmtpHost:                   "smtp",
+				SmtpPort:                   587,
+				SmtpFrom:                   "relayer@example.com",
+				SmtpTo:                     []string{"oncall@example.com"},
+			},
+			false,
+		}, {
+			&AlertConfig{
+				Moniker:                    "test",
+				BlockUpdateTimeOut:         10,
+				PackageDelayAlertThreshold: 10,
+				BlockUpdateCriticalTimeOut: 10,
+			},
+			true,
+		}, {
+			&AlertConfig{
+				Moniker:                    "test",
+				BlockUpdateTimeOut:         10,
+				PackageDelayAlertThreshold: 10,
+				PackageDelaySeverity:       "fatal",
+			},
+			true,
+		}, {
+			&AlertConfig{
+				Moniker:                    "test",
+				BlockUpdateTimeOut:         10,
+				PackageDelayAlertThreshold: 10,
+				PagerDutySummary:           "{{.Moniker",
+			},
+			true,
+		}, {
+			&AlertConfig{
+				Moniker:                       "test",
+				BlockUpdateTimeOut:            10,
+				PackageDelayAlertThreshold:    10,
+				BlockUpdateSeverity:           SeverityWarning,
+				BlockUpdateCriticalTimeOut:    60,
+				PackageDelaySeverity:          SeverityWarning,
+				PackageDelayCriticalThreshold: 60,
+			},
+			false,
 		},
 	}
 
 	for _, config := range cases {
+		err := config.config.Validate()
 		if config.result {
-			require.Panics(t, config.config.Validate, "the check should panic")
+			require.NotNil(t, err, "the check should fail")
 		} else {
-			require.NotPanics(t, config.config.Validate, "the check should not panic")
+			require.Nil(t, err, "the check should pass")
 		}
 	}
 }
@@ -73,10 +130,11 @@ func TestDBConfig(t *testing.T) {
 	}
 
 	for _, config := range cases {
+		err := config.config.Validate()
 		if config.result {
-			require.Panics(t, config.config.Validate, "the check should panic")
+			require.NotNil(t, err, "the check should fail")
 		} else {
-			require.NotPanics(t, config.config.Validate, "the check should not panic")
+			require.Nil(t, err, "the check should pass")
 		}
 	}
 }
@@ -112,6 +170,15 @@ func TestChainConfig(t *testing.T) {
 				ASCCrossChainContractAddress: ethcmm.Address{},
 			},
 			true,
+		}, {
+			&ChainConfig{
+				ASCStartHeight:               1,
+				ASCProviders:                 []string{"provider"},
+				ASCConfirmNum:                1,
+				ASCConfirmMode:               "wrong",
+				ASCCrossChainContractAddress: ethcmm.Address{1},
+			},
+			true,
 		}, {
 			&ChainConfig{
 				ASCStartHeight:               1,
@@ -184,6 +251,55 @@ func TestChainConfig(t *testing.T) {
 				ASCConfirmNum:                1,
 				ASCCrossChainContractAddress: ethcmm.Address{1},
 				AFCRpcAddrs:                  []string{"rpc addr"},
+				AFCKeyType:                   KeyTypeKeystore,
+				AFCKeystoreFile:              "keystore.json",
+				RelayInterval:                1,
+			},
+			true,
+		}, {
+			&ChainConfig{
+				ASCStartHeight:               1,
+				ASCProviders:                 []string{"provider"},
+				ASCConfirmNum:                1,
+				ASCCrossChainContractAddress: ethcmm.Address{1},
+				AFCRpcAddrs:                  []string{"rpc addr"},
+				AFCKeyType:                   KeyTypeKeystore,
+				AFCKeystoreFile:              "keystore.json",
+				AFCKeystorePasswordEnv:       "KEYSTORE_PASSWORD",
+				RelayInterval:                1,
+			},
+			false,
+		}, {
+			&ChainConfig{
+				ASCStartHeight:               1,
+				ASCProviders:                 []string{"provider"},
+				ASCConfirmNum:                1,
+				ASCCrossChainContractAddress: ethcmm.Address{1},
+				AFCRpcAddrs:                  []string{"rpc addr"},
+				AFCKeyType:                   KeyTypeRemoteSigner,
+				RelayInterval:                1,
+			},
+			true,
+		}, {
+			&ChainConfig{
+				ASCStartHeight:               1,
+				ASCProviders:                 []string{"provider"},
+				ASCConfirmNum:                1,
+				ASCCrossChainContractAddress: ethcmm.Address{1},
+				AFCRpcAddrs:                  []string{"rpc addr"},
+				AFCKeyType:                   KeyTypeMnemonic,
+				AFCMnemonic:                  "mnemonic",
+				RelayInterval:                1,
+			},
+			false,
+		}, {
+			// asc_confirm_num is not required unless the confirm mode is depth
+			&ChainConfig{
+				ASCStartHeight:               1,
+				ASCProviders:                 []string{"provider"},
+				ASCConfirmMode:               ConfirmModeFinalized,
+				ASCCrossChainContractAddress: ethcmm.Address{1},
+				AFCRpcAddrs:                  []string{"rpc addr"},
 				AFCKeyType:                   KeyTypeMnemonic,
 				AFCMnemonic:                  "mnemonic",
 				RelayInterval:                1,
@@ -193,12 +309,18 @@ func TestChainConfig(t *testing.T) {
 	}
 
 	for _, config := range cases {
+		err := config.config.Validate()
 		if config.result {
-			require.Panics(t, config.config.Validate, "the check should panic")
+			require.NotNil(t, err, "the check should fail")
 		} else {
-			require.NotPanics(t, config.config.Validate, "the check should not panic")
+			require.Nil(t, err, "the check should pass")
 		}
 	}
+
+	// asc_confirm_num is kept in the modes other than depth
+	config := &ChainConfig{ASCConfirmMode: ConfirmModeSafe, ASCConfirmNum: 1}
+	config.validate(newValidator(""))
+	require.Equal(t, int64(1), config.ASCConfirmNum)
 }
 
 func TestLogConfig(t *testing.T) {
@@ -231,10 +353,182 @@ func TestLogConfig(t *testing.T) {
 	}
 
 	for _, config := range cases {
+		err := config.config.Validate()
 		if config.result {
-			require.Panics(t, config.config.Validate, "the check should panic")
+			require.NotNil(t, err, "the check should fail")
 		} else {
-			require.NotPanics(t, config.config.Validate, "the check should not panic")
+			require.Nil(t, err, "the check should pass")
 		}
 	}
 }
+
+func TestParseConfigFromJson_chainConfigList(t *testing.T) {
+	config := GetTestConfig()
+	require.Len(t, config.ChainConfigs, 1)
+	require.Equal(t, config.ChainConfigs[0], config.ChainConfig)
+
+	config, err := ParseConfigFromJson(`{
+  "chain_config": [
+    {
+      "asc_chain_id": 96,
+      "asc_providers": ["provider_1"],
+      "asc_confirm_num": 15,
+      "asc_cross_chain_contract_address": "0x0000000000000000000000000000000000001004",
+      "afc_rpc_addrs": ["afc_rpc_addr"],
+      "afc_key_type": "mnemonic",
+      "afc_mnemonic": "mnemonic",
+      "relay_interval": 1000
+    },
+    {
+      "asc_chain_name": "sidechain",
+      "asc_chain_id": 97,
+      "asc_providers": ["provider_2"],
+      "asc_confirm_num": 15,
+      "asc_cross_chain_contract_address": "0x0000000000000000000000000000000000001004"
+    }
+  ]
+}`)
+	require.Nil(t, err)
+	require.Len(t, config.ChainConfigs, 2)
+	require.Equal(t, uint16(96), config.ChainConfig.ASCChainId)
+
+	err = validateChainConfigs(config)
+	require.Nil(t, err)
+	require.Equal(t, "mnemonic", config.ChainConfigs[1].AFCMnemonic)
+	require.Equal(t, int64(1000), config.ChainConfigs[1].RelayInterval)
+
+	config.ChainConfigs[1].ASCChainId = 96
+	requireFieldErrors(t, validateChainConfigs(config), "chain_config[1].asc_chain_id")
+
+	config.ChainConfigs[1].ASCChainId = 97
+	config.ChainConfigs[1].ASCChainName = ""
+	requireFieldErrors(t, validateChainConfigs(config), "chain_config[1].asc_chain_name")
+
+	config.ChainConfigs[1].ASCChainName = "sidechain"
+	config.ChainConfigs[1].AFCMnemonic = "another mnemonic"
+	requireFieldErrors(t, validateChainConfigs(config), "chain_config[1].afc_key_type")
+
+	config.ChainConfigs[1].AFCMnemonic = "mnemonic"
+	config.ChainConfigs[1].AFCRpcAddrs = []string{"another_afc_rpc_addr"}
+	requireFieldErrors(t, validateChainConfigs(config), "chain_config[1].afc_rpc_addrs")
+}
+
+func validateChainConfigs(config *Config) error {
+	v := newValidator("")
+	config.validateChainConfigs(v)
+	return v.err()
+}
+
+func requireFieldErrors(t *testing.T, err error, paths ...string) {
+	require.NotNil(t, err)
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok, "error should be a validation error")
+
+	errPaths := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		errPaths = append(errPaths, fieldErr.Path)
+	}
+	require.Equal(t, paths, errPaths)
+}
+
+func TestConfig_Validate_allProblems(t *testing.T) {
+	config, err := ParseConfigFromJson(`{
+  "db_config": {
+    "dialect": "postgres",
+    "db_path": "path"
+  },
+  "chain_config": {
+    "asc_chain_id": 96,
+    "asc_providers": [],
+    "asc_confirm_num": 15,
+    "asc_cross_chain_contract_address": "0x0000000000000000000000000000000000001004",
+    "afc_rpc_addrs": ["afc_rpc_addr"],
+    "afc_key_type": "mnemonic",
+    "afc_mnemonic": "mnemonic",
+    "relay_interval": 0
+  },
+  "log_config": {
+    "level": "INFO"
+  }
+}`)
+	require.Nil(t, err)
+
+	err = config.Validate()
+	requireFieldErrors(t, err, "db_config.dialect", "chain_config.asc_providers", "chain_config.relay_interval",
+		"alert_config")
+	require.Contains(t, err.Error(), "chain_config.asc_providers: should not be empty")
+}
+
+func TestParseConfig_formats(t *testing.T) {
+	jsonConfig, err := ParseConfig([]byte(`{
+  "db_config": {"dialect": "sqlite3", "db_path": "relayer.db"},
+  "chain_config": [
+    {
+      "asc_chain_id": 96,
+      "asc_providers": ["provider_1"],
+      "asc_cross_chain_contract_address": "0x0000000000000000000000000000000000001004",
+      "relay_interval": 1000
+    },
+    {"asc_chain_name": "sidechain", "asc_chain_id": 97}
+  ],
+  "alert_config": {"moniker": "moniker", "webhook_headers": {"Authorization": "Bearer token"}}
+}`), ConfigFormatJson)
+	require.Nil(t, err)
+
+	yamlConfig, err := ParseConfig([]byte(`
+db_config:
+  dialect: sqlite3
+  db_path: relayer.db
+chain_config:
+  - asc_chain_id: 96
+    asc_providers: [provider_1]
+    # addresses should be quoted, or they are parsed as numbers
+    asc_cross_chain_contract_address: "0x0000000000000000000000000000000000001004"
+    relay_interval: 1000
+  - asc_chain_name: sidechain
+    asc_chain_id: 97
+alert_config:
+  moniker: moniker
+  webhook_headers:
+    Authorization: Bearer token
+`), ConfigFormatYaml)
+	require.Nil(t, err)
+	require.Equal(t, jsonConfig, yamlConfig)
+
+	tomlConfig, err := ParseConfig([]byte(`
+[db_config]
+dialect = "sqlite3"
+db_path = "relayer.db"
+
+[[chain_config]]
+asc_chain_id = 96
+asc_providers = ["provider_1"]
+asc_cross_chain_contract_address = "0x0000000000000000000000000000000000001004"
+relay_interval = 1000
+
+[[chain_config]]
+asc_chain_name = "sidechain"
+asc_chain_id = 97
+
+[alert_config]
+moniker = "moniker"
+
+[alert_config.webhook_headers]
+Authorization = "Bearer token"
+`), ConfigFormatToml)
+	require.Nil(t, err)
+	require.Equal(t, jsonConfig, tomlConfig)
+
+	_, err = ParseConfig([]byte(`db_config: [`), ConfigFormatYaml)
+	require.NotNil(t, err)
+	_, err = ParseConfig([]byte(`{}`), "xml")
+	require.NotNil(t, err)
+}
+
+func TestConfigFormatOfPath(t *testing.T) {
+	require.Equal(t, ConfigFormatYaml, ConfigFormatOfPath("config/config.yaml"))
+	require.Equal(t, ConfigFormatYaml, ConfigFormatOfPath("config.YML"))
+	require.Equal(t, ConfigFormatToml, ConfigFormatOfPath("config.toml"))
+	require.Equal(t, ConfigFormatJson, ConfigFormatOfPath("config.json"))
+	require.Equal(t, ConfigFormatJson, ConfigFormatOfPath("config"))
+}
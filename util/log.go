@@ -13,6 +13,9 @@ var (
 	Logger    = logging.MustGetLogger("deputy")
 	SdkLogger = &sdkLogger{}
 
+	// leveledBackends are the backends of the logger, kept to change their level at runtime
+	leveledBackends []logging.LeveledBackend
+
 	// log levels that are available
 	levels = map[string]logging.Level{
 		"CRITICAL": logging.CRITICAL,
@@ -27,6 +30,7 @@ var (
 // InitLogger initialises the logger.
 func InitLogger(config LogConfig) {
 	backends := make([]logging.Backend, 0)
+	leveledBackends = make([]logging.LeveledBackend, 0)
 
 	if config.UseConsoleLogger {
 		consoleFormat := logging.MustStringFormatter(`%{time:2006-01-02 15:04:05} %{level} %{shortfunc} %{message}`)
@@ -35,6 +39,7 @@ func InitLogger(config LogConfig) {
 		consoleLoggerLeveled := logging.AddModuleLevel(consoleFormatter)
 		consoleLoggerLeveled.SetLevel(levels[config.Level], "")
 		backends = append(backends, consoleLoggerLeveled)
+		leveledBackends = append(leveledBackends, consoleLoggerLeveled)
 	}
 
 	if config.UseFileLogger {
@@ -50,11 +55,19 @@ func InitLogger(config LogConfig) {
 		fileLoggerLeveled := logging.AddModuleLevel(fileFormatter)
 		fileLoggerLeveled.SetLevel(levels[config.Level], "")
 		backends = append(backends, fileLoggerLeveled)
+		leveledBackends = append(leveledBackends, fileLoggerLeveled)
 	}
 
 	logging.SetBackend(backends...)
 }
 
+// SetLogLevel changes the level of the logger initialized by InitLogger
+func SetLogLevel(level string) {
+	for _, backend := range leveledBackends {
+		backend.SetLevel(levels[level], "")
+	}
+}
+
 type sdkLogger struct {
 }
 
@@ -0,0 +1,252 @@
+package util
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the env vars overriding config fields
+const EnvPrefix = "RELAYER"
+
+const chainConfigField = "chain_config"
+
+// pathSegment is one segment of a config field path, index is -1 if the segment is not indexed
+type pathSegment struct {
+	name  string
+	index int
+}
+
+// parsePath parses the json path of a config field, eg(chain_config[1].asc_providers)
+func parsePath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty config path")
+	}
+
+	parts := strings.Split(path, ".")
+	segments := make([]pathSegment, 0, len(parts))
+	for _, part := range parts {
+		segment := pathSegment{name: part, index: -1}
+		if open := strings.Index(part, "["); open >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("invalid config path %s", path)
+			}
+			index, err := strconv.Atoi(part[open+1 : len(part)-1])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index of config path %s", path)
+			}
+			segment.name = part[:open]
+			segment.index = index
+		}
+		if segment.name == "" {
+			return nil, fmt.Errorf("invalid config path %s", path)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// envName returns the env var name of the config path, eg(RELAYER_CHAIN_CONFIG_1_ASC_PROVIDERS)
+func envName(path string) string {
+	replacer := strings.NewReplacer(".", "_", "[", "_", "]", "")
+	return strings.ToUpper(EnvPrefix + "_" + replacer.Replace(path))
+}
+
+// jsonName returns the json name of the struct field, or "" if the field is not in json
+func jsonName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// leafPaths returns the paths of all the fields which can be set by one value under the given type
+func leafPaths(t reflect.Type, prefix string) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return []string{prefix}
+	}
+
+	paths := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		paths = append(paths, leafPaths(t.Field(i).Type, path)...)
+	}
+	return paths
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ApplyEnvOverrides overrides the config fields by the env vars named after their json paths, eg(
+// RELAYER_CHAIN_CONFIG_RELAY_INTERVAL for chain_config.relay_interval). Fields of the chain config at index i
+// are overridden by RELAYER_CHAIN_CONFIG_<i>_<FIELD>, and a chain config is appended if i is the number of chain
+// configs.
+func (cfg *Config) ApplyEnvOverrides(lookupEnv func(key string) (string, bool)) error {
+	configType := reflect.TypeOf(cfg).Elem()
+	for i := 0; i < configType.NumField(); i++ {
+		name := jsonName(configType.Field(i))
+		if name == "" || name == chainConfigField {
+			continue
+		}
+		for _, path := range leafPaths(configType.Field(i).Type, name) {
+			if err := cfg.applyEnv(lookupEnv, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	chainPaths := leafPaths(reflect.TypeOf(ChainConfig{}), "")
+	for _, path := range chainPaths {
+		if err := cfg.applyEnv(lookupEnv, chainConfigField+"."+path); err != nil {
+			return err
+		}
+	}
+	// the number of chain configs grows if a chain config is appended
+	for idx := 0; idx <= len(cfg.ChainConfigs); idx++ {
+		for _, path := range chainPaths {
+			if err := cfg.applyEnv(lookupEnv, fmt.Sprintf("%s[%d].%s", chainConfigField, idx, path)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) applyEnv(lookupEnv func(key string) (string, bool), path string) error {
+	value, ok := lookupEnv(envName(path))
+	if !ok {
+		return nil
+	}
+	if err := cfg.Set(path, value); err != nil {
+		return fmt.Errorf("override config by env %s error, err=%s", envName(path), err.Error())
+	}
+	return nil
+}
+
+// ApplyOverrides overrides the config fields by settings in the form of path=value, eg(
+// chain_config[1].asc_providers=https://provider_1,https://provider_2)
+func (cfg *Config) ApplyOverrides(settings []string) error {
+	for _, setting := range settings {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid config setting %q, it should be path=value", setting)
+		}
+		if err := cfg.Set(strings.TrimSpace(parts[0]), parts[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set sets the config field of the json path by the given value. Strings are taken as they are, string lists can
+// be comma separated values, other values are parsed as json.
+func (cfg *Config) Set(path string, value string) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	if segments[0].name != chainConfigField {
+		if segments[0].index >= 0 {
+			return fmt.Errorf("%s is not a list", segments[0].name)
+		}
+		return setStructField(reflect.ValueOf(cfg).Elem(), segments, path, value)
+	}
+
+	idx := segments[0].index
+	if idx < 0 {
+		idx = 0
+	}
+	if idx > len(cfg.ChainConfigs) {
+		return fmt.Errorf("index of %s out of range, there are %d chain configs", path, len(cfg.ChainConfigs))
+	}
+	if idx == len(cfg.ChainConfigs) {
+		cfg.ChainConfigs = append(cfg.ChainConfigs, &ChainConfig{})
+	}
+	if len(cfg.ChainConfigs) > 1 {
+		cfg.chainConfigList = true
+	}
+	cfg.ChainConfig = cfg.ChainConfigs[0]
+	return setStructField(reflect.ValueOf(cfg.ChainConfigs[idx]).Elem(), segments[1:], path, value)
+}
+
+func setStructField(v reflect.Value, segments []pathSegment, path string, value string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("%s is not a config field", path)
+	}
+
+	segment := segments[0]
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) != segment.name || segment.name == chainConfigField {
+			continue
+		}
+		if segment.index >= 0 {
+			return fmt.Errorf("%s is not a list", segment.name)
+		}
+
+		field := v.Field(i)
+		if len(segments) == 1 {
+			if err := setValue(field, value); err != nil {
+				return fmt.Errorf("invalid value of %s, err=%s", path, err.Error())
+			}
+			return nil
+		}
+
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("%s is not a config section", segment.name)
+		}
+		return setStructField(field, segments[1:], path, value)
+	}
+	return fmt.Errorf("%s is not a config field", path)
+}
+
+func setValue(field reflect.Value, value string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+		return nil
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String &&
+		!strings.HasPrefix(strings.TrimSpace(value), "["):
+		values := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+		return nil
+	default:
+		target := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), target.Interface()); err != nil {
+			return err
+		}
+		field.Set(target.Elem())
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"testing"
+
+	ethcmm "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ApplyEnvOverrides(t *testing.T) {
+	config := GetTestConfig()
+
+	env := map[string]string{
+		"RELAYER_CHAIN_CONFIG_RELAY_INTERVAL":                     "2000",
+		"RELAYER_CHAIN_CONFIG_ASC_PROVIDERS":                      "https://provider_1, https://provider_2",
+		"RELAYER_CHAIN_CONFIG_1_ASC_CHAIN_ID":                     "97",
+		"RELAYER_CHAIN_CONFIG_1_ASC_CHAIN_NAME":                   "sidechain",
+		"RELAYER_CHAIN_CONFIG_1_ASC_CROSS_CHAIN_CONTRACT_ADDRESS": "0x0000000000000000000000000000000000001005",
+		"RELAYER_LOG_CONFIG_USE_FILE_LOGGER":                      "true",
+		"RELAYER_ALERT_CONFIG_WEBHOOK_HEADERS":                    `{"Authorization": "Bearer token"}`,
+		"RELAYER_ADMIN_CONFIG_AUTH_TOKEN":                         "token",
+	}
+	err := config.ApplyEnvOverrides(func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+	require.Nil(t, err)
+
+	require.Equal(t, int64(2000), config.ChainConfig.RelayInterval)
+	require.Equal(t, []string{"https://provider_1", "https://provider_2"}, config.ChainConfig.ASCProviders)
+	require.Len(t, config.ChainConfigs, 2)
+	require.Equal(t, uint16(97), config.ChainConfigs[1].ASCChainId)
+	require.Equal(t, "sidechain", config.ChainConfigs[1].ASCChainName)
+	require.Equal(t, ethcmm.HexToAddress("0x0000000000000000000000000000000000001005"),
+		config.ChainConfigs[1].ASCCrossChainContractAddress)
+	require.True(t, config.LogConfig.UseFileLogger)
+	require.Equal(t, map[string]string{"Authorization": "Bearer token"}, config.AlertConfig.WebhookHeaders)
+	require.Equal(t, "token", config.AdminConfig.AuthToken)
+
+	err = config.ApplyEnvOverrides(func(key string) (string, bool) {
+		if key == "RELAYER_CHAIN_CONFIG_ASC_CHAIN_ID" {
+			return "not a number", true
+		}
+		return "", false
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "RELAYER_CHAIN_CONFIG_ASC_CHAIN_ID")
+}
+
+func TestConfig_ApplyOverrides(t *testing.T) {
+	config := GetTestConfig()
+
+	err := config.ApplyOverrides([]string{
+		"chain_config.asc_providers=[\"https://provider_1\"]",
+		"chain_config[0].relay_interval=3000",
+		"alert_config.moniker=relayer=1",
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"https://provider_1"}, config.ChainConfig.ASCProviders)
+	require.Equal(t, int64(3000), config.ChainConfig.RelayInterval)
+	require.Equal(t, "relayer=1", config.AlertConfig.Moniker)
+
+	cases := []string{
+		"chain_config.relay_interval",
+		"chain_config[2].relay_interval=1",
+		"chain_config.unknown=1",
+		"log_config[0].level=INFO",
+		"db_config=1",
+	}
+	for _, setting := range cases {
+		require.NotNil(t, config.ApplyOverrides([]string{setting}), setting)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	require.Equal(t, "RELAYER_CHAIN_CONFIG_RELAY_INTERVAL", envName("chain_config.relay_interval"))
+	require.Equal(t, "RELAYER_CHAIN_CONFIG_1_ASC_PROVIDERS", envName("chain_config[1].asc_providers"))
+}
@@ -0,0 +1,165 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// reloadableFields are the config fields which can be changed without restart, chain config fields are without
+// the chain index, and sections end with "."
+var reloadableFields = []string{
+	"chain_config.asc_providers",
+	"chain_config.afc_rpc_addrs",
+	"chain_config.relay_interval",
+	"alert_config.",
+	"log_config.level",
+	"admin_config.auth_token",
+}
+
+var chainIndexRegexp = regexp.MustCompile(`^chain_config\[\d+\]`)
+
+func isReloadable(path string) bool {
+	path = chainIndexRegexp.ReplaceAllString(path, chainConfigField)
+	for _, field := range reloadableFields {
+		if path == field || (strings.HasSuffix(field, ".") && strings.HasPrefix(path, field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes returns the paths of the fields changed by the new config, which should have been validated. If any field
+// which can not be changed without restart is changed, eg(the afc key, chain ids or the db), the problems are
+// returned as a ValidationError.
+func (cfg *Config) Changes(newCfg *Config) ([]string, error) {
+	v := newValidator("")
+	if len(newCfg.ChainConfigs) != len(cfg.ChainConfigs) {
+		v.addf(chainConfigField, "number of chains can not be changed without restart")
+		return nil, v.err()
+	}
+	if cfg.AdminConfig == nil {
+		cfg.mtx.Lock()
+		cfg.AdminConfig = &AdminConfig{}
+		cfg.mtx.Unlock()
+	}
+	if newCfg.AdminConfig == nil {
+		newCfg.AdminConfig = &AdminConfig{}
+	}
+
+	changed := make([]string, 0)
+	for _, path := range cfg.fieldPaths() {
+		oldValue, newValue := cfg.fieldByPath(path), newCfg.fieldByPath(path)
+		if reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			continue
+		}
+		if !isReloadable(path) {
+			v.addf(path, "can not be changed without restart")
+			continue
+		}
+		changed = append(changed, path)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// Reload applies the changes of the new config to the config and returns the paths of the changed fields, nothing
+// is applied if Changes returns an error. The changes are applied at once while holding the lock, the running
+// components should read the reloadable fields by the accessors below.
+func (cfg *Config) Reload(newCfg *Config) ([]string, error) {
+	changed, err := cfg.Changes(newCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+
+	for _, path := range changed {
+		cfg.fieldByPath(path).Set(newCfg.fieldByPath(path))
+	}
+	return changed, nil
+}
+
+// Alert returns a copy of the alert config, which is not changed by the following reloads
+func (cfg *Config) Alert() *AlertConfig {
+	cfg.mtx.RLock()
+	defer cfg.mtx.RUnlock()
+
+	alertConfig := *cfg.AlertConfig
+	return &alertConfig
+}
+
+// LogLevel returns the log level of the log config
+func (cfg *Config) LogLevel() string {
+	cfg.mtx.RLock()
+	defer cfg.mtx.RUnlock()
+
+	return cfg.LogConfig.Level
+}
+
+// AdminAuthToken returns the auth token of the admin server, it is empty if the admin config is not set
+func (cfg *Config) AdminAuthToken() string {
+	cfg.mtx.RLock()
+	defer cfg.mtx.RUnlock()
+
+	if cfg.AdminConfig == nil {
+		return ""
+	}
+	return cfg.AdminConfig.AuthToken
+}
+
+// RelayInterval returns the relay interval of the given chain config of the config
+func (cfg *Config) RelayInterval(chainConfig *ChainConfig) int64 {
+	cfg.mtx.RLock()
+	defer cfg.mtx.RUnlock()
+
+	return chainConfig.RelayInterval
+}
+
+// fieldPaths returns the json paths of all the fields of the config, chain config fields are indexed
+func (cfg *Config) fieldPaths() []string {
+	paths := make([]string, 0)
+	configType := reflect.TypeOf(cfg).Elem()
+	for i := 0; i < configType.NumField(); i++ {
+		name := jsonName(configType.Field(i))
+		if name == "" || name == chainConfigField {
+			continue
+		}
+		paths = append(paths, leafPaths(configType.Field(i).Type, name)...)
+	}
+
+	chainPaths := leafPaths(reflect.TypeOf(ChainConfig{}), "")
+	for idx := range cfg.ChainConfigs {
+		for _, path := range chainPaths {
+			paths = append(paths, fmt.Sprintf("%s[%d].%s", chainConfigField, idx, path))
+		}
+	}
+	return paths
+}
+
+// fieldByPath returns the field of the json path returned by fieldPaths, sections of the path should not be nil
+func (cfg *Config) fieldByPath(path string) reflect.Value {
+	segments, err := parsePath(path)
+	if err != nil {
+		panic(err)
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	if segments[0].name == chainConfigField {
+		v = reflect.ValueOf(cfg.ChainConfigs[segments[0].index]).Elem()
+		segments = segments[1:]
+	}
+	for _, segment := range segments {
+		for i := 0; i < v.NumField(); i++ {
+			if jsonName(v.Type().Field(i)) == segment.name {
+				v = reflect.Indirect(v.Field(i))
+				break
+			}
+		}
+	}
+	return v
+}
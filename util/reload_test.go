@@ -0,0 +1,64 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Reload(t *testing.T) {
+	config := GetTestConfig()
+	chainConfig := config.ChainConfig
+	alertConfig := config.Alert()
+
+	newConfig := GetTestConfig()
+	newConfig.ChainConfig.ASCProviders = []string{"https://provider_1"}
+	newConfig.ChainConfig.RelayInterval = 2000
+	newConfig.AlertConfig.BlockUpdateTimeOut = 120
+	newConfig.LogConfig.Level = "DEBUG"
+
+	changed, err := config.Reload(newConfig)
+	require.Nil(t, err)
+	require.ElementsMatch(t, []string{
+		"chain_config[0].asc_providers",
+		"chain_config[0].relay_interval",
+		"alert_config.block_update_time_out",
+		"log_config.level",
+	}, changed)
+
+	// the running components see the changes by the accessors, the copies read before are not changed
+	require.Equal(t, []string{"https://provider_1"}, chainConfig.ASCProviders)
+	require.Equal(t, int64(2000), config.RelayInterval(chainConfig))
+	require.Equal(t, int64(120), config.Alert().BlockUpdateTimeOut)
+	require.NotEqual(t, int64(120), alertConfig.BlockUpdateTimeOut)
+	require.Equal(t, "DEBUG", config.LogLevel())
+
+	// the websocket providers are only subscribed at startup
+	newConfig = GetTestConfig()
+	newConfig.ChainConfig.ASCWsProviders = []string{"wss://provider_1"}
+	_, err = config.Reload(newConfig)
+	requireFieldErrors(t, err, "chain_config[0].asc_ws_providers")
+}
+
+func TestConfig_Reload_rejected(t *testing.T) {
+	config := GetTestConfig()
+
+	newConfig := GetTestConfig()
+	newConfig.ChainConfig.RelayInterval = 2000
+	newConfig.ChainConfig.ASCChainId = 97
+	newConfig.ChainConfig.AFCMnemonic = "another mnemonic"
+	newConfig.DBConfig.DBPath = "another db"
+
+	_, err := config.Reload(newConfig)
+	requireFieldErrors(t, err,
+		"db_config.db_path",
+		"chain_config[0].asc_chain_id",
+		"chain_config[0].afc_mnemonic",
+	)
+	require.Equal(t, int64(1000), config.ChainConfig.RelayInterval)
+
+	newConfig = GetTestConfig()
+	newConfig.ChainConfigs = append(newConfig.ChainConfigs, newConfig.ChainConfig)
+	_, err = config.Reload(newConfig)
+	requireFieldErrors(t, err, "chain_config")
+}
@@ -18,6 +18,7 @@ var testConfig = `
     "asc_start_height": 1,
     "asc_providers": ["asc_provider"],
     "asc_confirm_num": 2,
+    "asc_chain_id": 96,
     "asc_cross_chain_contract_address": "0x0000000000000000000000000000000000001004",
 
     "afc_rpc_addrs": ["afc_rpc_addr"],
@@ -51,7 +52,10 @@ var testConfig = `
 `
 
 func GetTestConfig() *Config {
-	config := ParseConfigFromJson(testConfig)
+	config, err := ParseConfigFromJson(testConfig)
+	if err != nil {
+		panic(err)
+	}
 	return config
 }
 
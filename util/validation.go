@@ -0,0 +1,76 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a problem of the config field at the json path, eg(chain_config.asc_providers)
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationError lists all the problems found in a config
+type ValidationError struct {
+	Errors []*FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.Error())
+	}
+	return fmt.Sprintf("invalid config, %d problem(s) found:\n%s", len(e.Errors), strings.Join(messages, "\n"))
+}
+
+// validator collects the problems of a config section whose json path is path
+type validator struct {
+	path   string
+	errors *[]*FieldError
+}
+
+func newValidator(path string) *validator {
+	return &validator{
+		path:   path,
+		errors: &[]*FieldError{},
+	}
+}
+
+// sub returns the validator of the given section of the current one, sharing the collected problems
+func (v *validator) sub(section string) *validator {
+	return &validator{
+		path:   v.join(section),
+		errors: v.errors,
+	}
+}
+
+func (v *validator) join(field string) string {
+	if v.path == "" {
+		return field
+	}
+	if strings.HasPrefix(field, "[") {
+		return v.path + field
+	}
+	return v.path + "." + field
+}
+
+// addf records a problem of the given field
+func (v *validator) addf(field string, format string, args ...interface{}) {
+	*v.errors = append(*v.errors, &FieldError{
+		Path:    v.join(field),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns the ValidationError of the collected problems, or nil if there is none
+func (v *validator) err() error {
+	if len(*v.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: *v.errors}
+}